@@ -1,9 +1,15 @@
 package agent
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/modelcatalog"
 	"github.com/AnthonyL103/GOMCP/registry"
 	"github.com/AnthonyL103/GOMCP/server"
 	"github.com/AnthonyL103/GOMCP/tool"
@@ -36,11 +42,92 @@ type AgentDetails struct {
 	InfraGeneration  bool
 }
 
+const (
+	// DefaultMaxParallelTools caps how many tool calls from one turn run at once
+	DefaultMaxParallelTools = 4
+	// DefaultToolTimeout bounds a single tool call
+	DefaultToolTimeout = 60 * time.Second
+	// DefaultMaxRetries is how often a failed LLM request is retried
+	DefaultMaxRetries = 3
+	// DefaultLLMTimeout bounds one LLM request attempt
+	DefaultLLMTimeout = 5 * time.Minute
+	// DefaultMaxToolIterations caps the LLM calls in one turn
+	DefaultMaxToolIterations = 25
+	// DefaultMaxMessages is the sliding window of messages a chat keeps
+	DefaultMaxMessages = 50
+)
+
+// History controls how much of a conversation is sent to the model
+type History struct {
+	// MaxMessages caps the messages a chat keeps; 0 means no cap
+	MaxMessages int
+	// MaxTokens caps the estimated tokens of the history sent with each call;
+	// 0 fits it to the model's context window
+	MaxTokens int
+	// Summarize condenses the oldest turns into a running summary when they
+	// no longer fit, instead of dropping them
+	Summarize bool
+	// SummaryModel writes the summaries; it must be served by the same
+	// provider as LLMConfig.Model, and empty means LLMConfig.Model
+	SummaryModel string
+}
+
+// Budget holds hard limits enforced by the agent loop; zero means unlimited
+type Budget struct {
+	// MaxSessionTokens caps the tokens one chat session may use
+	MaxSessionTokens int
+	// MaxDailyCostUSD caps what the agent may spend per calendar day
+	MaxDailyCostUSD float64
+	// MaxToolIterations caps the LLM calls in one turn, so a model that keeps
+	// calling tools cannot loop forever
+	MaxToolIterations int
+}
+
+// LLM providers accepted in LLMConfig.Provider
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderOpenAI    = "openai"
+	ProviderGemini    = "gemini"
+	// ProviderOpenAICompatible talks the OpenAI Chat Completions API to any
+	// BaseURL (Ollama, vLLM, llama.cpp server) and accepts any model name
+	ProviderOpenAICompatible = "openai_compatible"
+)
+
 type LLMConfig struct {
 	APIKey      string
 	Model       string
 	Temperature float32
 	MaxTokens   int
+	// Provider picks the API explicitly; empty means detect it from Model
+	Provider string
+	// BaseURL overrides the provider's API root, e.g. http://localhost:11434/v1
+	BaseURL string
+	// MaxRetries is how often a failed request is retried: 0 uses
+	// DefaultMaxRetries, negative disables retries
+	MaxRetries int
+	// Timeout bounds each request attempt (for streams, until the response
+	// starts); 0 uses DefaultLLMTimeout
+	Timeout time.Duration
+	// Fallbacks are tried in order when a call to this model fails
+	Fallbacks []FallbackConfig
+}
+
+// Error classes a fallback can be triggered by
+const (
+	ErrorClassRateLimit     = "rate_limit"
+	ErrorClassOverloaded    = "overloaded"
+	ErrorClassContextLength = "context_length"
+	ErrorClassServer        = "server_error"
+)
+
+// DefaultFallbackOn is used when a fallback doesn't list its error classes
+var DefaultFallbackOn = []string{ErrorClassRateLimit, ErrorClassOverloaded, ErrorClassServer}
+
+// FallbackConfig is one link of a fallback chain
+type FallbackConfig struct {
+	LLM LLMConfig
+	// On lists the error classes that make the chain move on to this link
+	On []string
 }
 
 type Agent struct {
@@ -51,14 +138,89 @@ type Agent struct {
 	ServerGeneration bool
 	VoiceChat        bool
 	InfraGeneration  bool
+	// MaxParallelTools is the most tool calls from one turn run concurrently
+	MaxParallelTools int
+	// ToolTimeout bounds each tool call; zero means no per-call limit
+	ToolTimeout time.Duration
+
+	// Budget limits token use and spend
+	Budget Budget
+	// History limits the conversation sent with each LLM call
+	History History
+
+	// usage totals every LLM call the agent made, across all sessions;
+	// dailyUsage only covers usageDay, and includes what other runs spent
+	// that day when usageStore is set
+	usageMu    sync.Mutex
+	usage      chat.Usage
+	usageDay   string
+	dailyUsage chat.Usage
+	usageStore UsageStore
+}
+
+// UsageStore keeps each agent's daily usage between runs, so
+// max_daily_cost_usd holds across restarts and processes sharing the store
+type UsageStore interface {
+	// AddDailyUsage adds usage to the agent's total for day (YYYY-MM-DD)
+	// and returns the new total; an empty usage only reads the total
+	AddDailyUsage(agentID, day string, usage chat.Usage) (chat.Usage, error)
+}
+
+// SetUsageStore loads today's usage from store and saves every later call there
+func (a *Agent) SetUsageStore(store UsageStore) error {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+
+	today := time.Now().Format("2006-01-02")
+	daily, err := store.AddDailyUsage(a.AgentID, today, chat.Usage{})
+	if err != nil {
+		return fmt.Errorf("failed to load daily usage for agent %s: %w", a.AgentID, err)
+	}
+	a.usageStore = store
+	a.usageDay = today
+	a.dailyUsage = daily
+	return nil
+}
+
+// RecordUsage adds one LLM call's usage to the agent's totals. Store failures
+// are logged and the call is still counted in memory.
+func (a *Agent) RecordUsage(usage chat.Usage) {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	a.usage.Add(usage)
+
+	today := time.Now().Format("2006-01-02")
+	if a.usageDay != today {
+		a.usageDay = today
+		a.dailyUsage = chat.Usage{}
+	}
+
+	if a.usageStore != nil {
+		daily, err := a.usageStore.AddDailyUsage(a.AgentID, today, usage)
+		if err == nil {
+			a.dailyUsage = daily
+			return
+		}
+		log.Printf("Failed to save daily usage for agent %s: %v", a.AgentID, err)
+	}
+	a.dailyUsage.Add(usage)
 }
 
-// return list of valid models for the user
-func getModelList() []string {
-	return []string{
-		"gpt-4o", "gpt-4o-mini", "gpt-4-turbo", "o1-preview", "o1-mini",
-		"claude-opus-4-5-20251101", "claude-sonnet-4-5-20250929", "claude-haiku-4-5-20251001",
+// DailyUsage returns the agent's totals for the current calendar day
+func (a *Agent) DailyUsage() chat.Usage {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	if a.usageDay != time.Now().Format("2006-01-02") {
+		return chat.Usage{}
 	}
+	return a.dailyUsage
+}
+
+// Usage returns the agent's token and cost totals since it started
+func (a *Agent) Usage() chat.Usage {
+	a.usageMu.Lock()
+	defer a.usageMu.Unlock()
+	return a.usage
 }
 
 func validateLLMConfig(LLMConfig *LLMConfig) {
@@ -66,13 +228,30 @@ func validateLLMConfig(LLMConfig *LLMConfig) {
 		panic("LLMConfig cannot be nil")
 	}
 
-	if LLMConfig.APIKey == "" || !isString(LLMConfig.APIKey) {
+	switch LLMConfig.Provider {
+	case "", ProviderAnthropic, ProviderOpenAI, ProviderGemini, ProviderOpenAICompatible:
+	default:
+		panic(fmt.Sprintf("Invalid provider '%s'. Supported providers: %s, %s, %s, %s",
+			LLMConfig.Provider, ProviderAnthropic, ProviderOpenAI, ProviderGemini, ProviderOpenAICompatible))
+	}
+
+	// Local endpoints usually need no key
+	isCompatible := LLMConfig.Provider == ProviderOpenAICompatible
+	if isCompatible && LLMConfig.BaseURL == "" {
+		panic("LLMConfig.BaseURL is required for the openai_compatible provider")
+	}
+
+	if !isCompatible && (LLMConfig.APIKey == "" || !isString(LLMConfig.APIKey)) {
 		panic("LLMConfig.APIKey is required and must be a non-empty string")
 	}
 	if LLMConfig.Model == "" || !isString(LLMConfig.Model) {
 		panic("LLMConfig.Model is required and must be a non-empty string")
 	}
 
+	if LLMConfig.Timeout < 0 {
+		panic("LLMConfig.Timeout cannot be negative")
+	}
+
 	if LLMConfig.Temperature == 0 || !isFloat(LLMConfig.Temperature) {
 		panic("LLMConfig.Temperature is required and must be an float")
 	}
@@ -81,31 +260,46 @@ func validateLLMConfig(LLMConfig *LLMConfig) {
 		panic("LLMConfig.MaxTokens is required and must be an int")
 	}
 
-	validModels := map[string]bool{
-		// OpenAI GPT models (current)
-		"gpt-4o":              true,
-		"gpt-4o-mini":         true,
-		"gpt-4-turbo":         true,
-		"gpt-4-turbo-preview": true,
-		"o1-preview":          true,
-		"o1-mini":             true,
-		"gpt-3.5-turbo":       true, // legacy but still supported
+	for i := range LLMConfig.Fallbacks {
+		validateFallback(&LLMConfig.Fallbacks[i])
+	}
 
-		// Claude 4.5 models (latest)
-		"claude-opus-4-5-20251101":   true,
-		"claude-sonnet-4-5-20250929": true,
-		"claude-haiku-4-5-20251001":  true,
+	// Compatible endpoints serve whatever models they have installed
+	if isCompatible {
+		return
+	}
 
-		// Claude 3.5 models (legacy)
-		"claude-3-5-sonnet-20241022": true,
-		"claude-3-5-haiku-20241022":  true,
+	model, ok := modelcatalog.Lookup(LLMConfig.Model)
+	if !ok {
+		panic(fmt.Sprintf("Invalid model '%s'. Supported models: %v", LLMConfig.Model, modelcatalog.IDs()))
 	}
 
-	if !validModels[LLMConfig.Model] {
-		panic(fmt.Sprintf("Invalid model '%s'. Supported models: %v", LLMConfig.Model, getModelList()))
+	if LLMConfig.Provider != "" && LLMConfig.Provider != model.Provider {
+		panic(fmt.Sprintf("Model '%s' is served by %s, not %s", LLMConfig.Model, model.Provider, LLMConfig.Provider))
+	}
+
+	if model.MaxOutputTokens > 0 && LLMConfig.MaxTokens > model.MaxOutputTokens {
+		panic(fmt.Sprintf("LLMConfig.MaxTokens %d exceeds the %d output tokens '%s' supports", LLMConfig.MaxTokens, model.MaxOutputTokens, LLMConfig.Model))
 	}
 }
 
+func validateFallback(fallback *FallbackConfig) {
+	if len(fallback.LLM.Fallbacks) > 0 {
+		panic("fallbacks cannot have fallbacks of their own")
+	}
+
+	for _, class := range fallback.On {
+		switch class {
+		case ErrorClassRateLimit, ErrorClassOverloaded, ErrorClassContextLength, ErrorClassServer:
+		default:
+			panic(fmt.Sprintf("Invalid fallback error class '%s' for model '%s'. Supported classes: %s, %s, %s, %s",
+				class, fallback.LLM.Model, ErrorClassRateLimit, ErrorClassOverloaded, ErrorClassContextLength, ErrorClassServer))
+		}
+	}
+
+	validateLLMConfig(&fallback.LLM)
+}
+
 func NewAgent(
 	agentID string,
 	description string,
@@ -140,7 +334,40 @@ func NewAgent(
 		ServerGeneration: serverGeneration,
 		VoiceChat:        voiceChat,
 		InfraGeneration:  infraGeneration, // default to false, can be set via config
+		MaxParallelTools: DefaultMaxParallelTools,
+		ToolTimeout:      DefaultToolTimeout,
+		Budget:           Budget{MaxToolIterations: DefaultMaxToolIterations},
+		History:          History{MaxMessages: DefaultMaxMessages},
+	}
+}
+
+// NewChat starts a chat session sized by the agent's History settings. When
+// summarizing, turns only leave through the token limit so none are lost
+// without being summarized.
+func (a *Agent) NewChat(chatID string) *chat.Chat {
+	if a.History.Summarize {
+		return chat.NewChat(chatID, 0)
+	}
+	return chat.NewChat(chatID, a.History.MaxMessages)
+}
+
+// OpenChat resumes chatID from store, or starts it there if the store doesn't
+// have it. The chat is sized by the agent's current History settings and
+// saves every change to store.
+func (a *Agent) OpenChat(store chat.ChatStore, chatID string) (*chat.Chat, error) {
+	c, err := store.Load(chatID)
+	if errors.Is(err, chat.ErrChatNotFound) {
+		c = a.NewChat(chatID)
+	} else if err != nil {
+		return nil, err
+	} else {
+		c.MaxMessages = a.NewChat(chatID).MaxMessages
+	}
+
+	if err := c.SetStore(store); err != nil {
+		return nil, fmt.Errorf("failed to save chat %s: %w", chatID, err)
 	}
+	return c, nil
 }
 
 func (a *Agent) GetAgentDetails(agent *Agent) *AgentDetails {
@@ -153,12 +380,12 @@ func (a *Agent) GetAgentDetails(agent *Agent) *AgentDetails {
 	serverGeneration := a.ServerGeneration
 	toolCount := 0
 
-	for _, server := range a.Registry.Servers {
+	for _, server := range a.Registry.ListServers() {
 		servers[server.ServerID] = server
 		serverTools[server.ServerID] = make(map[string]*tool.Tool)
 
-		for toolName, tool := range server.Tools {
-			serverTools[server.ServerID][toolName] = tool
+		for _, tool := range server.ListTools() {
+			serverTools[server.ServerID][tool.ToolID] = tool
 			toolCount++
 		}
 	}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AnthonyL103/GOMCP/registry"
+)
+
+// RouterAgentID names the router agent built from the config's router block
+const RouterAgentID = "router"
+
+// Roster holds every agent defined in the config
+type Roster struct {
+	Agents []*Agent // in config order
+
+	// Default answers when no agent is chosen and there is no router: the
+	// agent named by default_agent, or the first one
+	Default *Agent
+
+	// Router, when set, picks the agent for each user message from the
+	// agents' descriptions. It has no servers of its own.
+	Router *Agent
+}
+
+// Get returns the agent with the given ID
+func (r *Roster) Get(agentID string) (*Agent, error) {
+	agentID = strings.TrimSpace(agentID)
+	for _, ag := range r.Agents {
+		if ag.AgentID == agentID {
+			return ag, nil
+		}
+	}
+	return nil, fmt.Errorf("agent '%s' does not exist (have %s)", agentID, strings.Join(r.AgentIDs(), ", "))
+}
+
+// All returns every agent followed by the router, if there is one
+func (r *Roster) All() []*Agent {
+	all := append([]*Agent{}, r.Agents...)
+	if r.Router != nil {
+		all = append(all, r.Router)
+	}
+	return all
+}
+
+// SetUsageStore attaches store to every agent and the router, so their daily
+// budgets count what earlier runs spent today
+func (r *Roster) SetUsageStore(store UsageStore) error {
+	for _, ag := range r.All() {
+		if err := ag.SetUsageStore(store); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AgentIDs lists the agents' IDs in config order
+func (r *Roster) AgentIDs() []string {
+	ids := make([]string, 0, len(r.Agents))
+	for _, ag := range r.Agents {
+		ids = append(ids, ag.AgentID)
+	}
+	return ids
+}
+
+// Servers collects every agent's servers into one registry, so servers that
+// several agents share are started and discovered once
+func (r *Roster) Servers() (*registry.Registry, error) {
+	servers := registry.NewRegistry()
+	for _, ag := range r.Agents {
+		for _, srv := range ag.Registry.ListServers() {
+			existing, err := servers.GetServer(srv.ServerID)
+			if err != nil {
+				servers.AddServer(srv)
+				continue
+			}
+			if existing != srv {
+				return nil, fmt.Errorf("server ID '%s' is defined by more than one server config", srv.ServerID)
+			}
+		}
+	}
+	return servers, nil
+}
@@ -0,0 +1,588 @@
+// Package api serves the HTTP endpoints used by the web client.
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
+	"github.com/AnthonyL103/GOMCP/transport"
+)
+
+const maxRequestBytes = 1 << 20
+
+// Session is one browser conversation backed by its own chat history and
+// providers. It either keeps the agent chosen when it was created or, with a
+// router, lets the router pick one for each message.
+type Session struct {
+	ID   string
+	Chat *chat.Chat
+
+	// current is the agent answering the session: the chosen one, or for a
+	// routed session the one that answered last (the default agent at first)
+	current   atomic.Pointer[agent.Agent]
+	router    *transport.Router             // nil unless routed
+	providers map[string]transport.Provider // by agent ID, created on first use
+
+	events *eventHub
+
+	// mu serializes turns; a provider loop must not run twice on one chat
+	mu sync.Mutex
+}
+
+// Server holds the chat sessions created through the API
+type Server struct {
+	roster      *agent.Roster
+	router      *transport.Router // nil without a router in the config
+	allowOrigin string
+
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// createSessionRequest is the optional body of POST /api/chat/session. An
+// empty AgentID routes each message when there is a router, and uses the
+// default agent otherwise.
+type createSessionRequest struct {
+	AgentID string `json:"agent_id"`
+}
+
+type createSessionResponse struct {
+	SessionID string `json:"session_id"`
+	AgentID   string `json:"agent_id,omitempty"` // empty when the router picks per message
+}
+
+type agentInfo struct {
+	AgentID     string `json:"agent_id"`
+	Description string `json:"description"`
+}
+
+type agentsResponse struct {
+	Agents  []agentInfo `json:"agents"`
+	Default string      `json:"default"`
+	Router  bool        `json:"router"` // sessions without an agent_id are routed
+}
+
+type messageRequest struct {
+	SessionID string `json:"session_id"`
+	Message   string `json:"message"`
+}
+
+type messageResponse struct {
+	Response string `json:"response"`
+	Agent    string `json:"agent"`            // agent that answered
+	Branch   string `json:"branch,omitempty"` // set when the message started a new branch
+}
+
+// branchRequest forks at Index, switches to Branch, or edits the user
+// message at Index to Message, depending on the endpoint
+type branchRequest struct {
+	SessionID string `json:"session_id"`
+	Index     int    `json:"index"`
+	Branch    string `json:"branch"`
+	Message   string `json:"message"`
+}
+
+type branchesResponse struct {
+	SessionID string            `json:"session_id"`
+	Active    string            `json:"active"`
+	Branches  []chat.BranchInfo `json:"branches"`
+}
+
+type messagesResponse struct {
+	SessionID string         `json:"session_id"`
+	Branch    string         `json:"branch"`
+	Messages  []chat.Message `json:"messages"`
+}
+
+type usageResponse struct {
+	SessionID string     `json:"session_id"`
+	AgentID   string     `json:"agent_id"`
+	Session   chat.Usage `json:"session"` // every LLM call in this session
+	Agent     chat.Usage `json:"agent"`   // every LLM call of the session's agent since the server started
+}
+
+// NewServer creates an API server for the roster's agents. allowOrigin is
+// sent as the CORS Access-Control-Allow-Origin header and may also open the
+// WebSocket; leave empty to allow same-origin requests only.
+func NewServer(roster *agent.Roster, allowOrigin string) (*Server, error) {
+	s := &Server{
+		roster:      roster,
+		allowOrigin: allowOrigin,
+		sessions:    make(map[string]*Session),
+	}
+
+	if roster.Router != nil {
+		router, err := transport.NewRouter(roster)
+		if err != nil {
+			return nil, err
+		}
+		s.router = router
+	}
+	return s, nil
+}
+
+// Handler returns the routes of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/health", s.handleHealth)
+	mux.HandleFunc("/api/agents", s.handleAgents)
+	mux.HandleFunc("/api/chat/session", s.handleCreateSession)
+	mux.HandleFunc("/api/chat/message", s.handleMessage)
+	mux.HandleFunc("/api/chat/ws", s.handleEvents)
+	mux.HandleFunc("/api/chat/usage", s.handleUsage)
+	mux.HandleFunc("/api/chat/messages", s.handleMessages)
+	mux.HandleFunc("/api/chat/branches", s.handleBranches)
+	mux.HandleFunc("/api/chat/fork", s.handleFork)
+	mux.HandleFunc("/api/chat/switch", s.handleSwitch)
+	mux.HandleFunc("/api/chat/edit", s.handleEdit)
+	return s.withCORS(mux)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := agentsResponse{
+		Agents:  make([]agentInfo, 0, len(s.roster.Agents)),
+		Default: s.roster.Default.AgentID,
+		Router:  s.router != nil,
+	}
+	for _, ag := range s.roster.Agents {
+		resp.Agents = append(resp.Agents, agentInfo{AgentID: ag.AgentID, Description: ag.Description})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The body is optional
+	var req createSessionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var pinned *agent.Agent
+	if strings.TrimSpace(req.AgentID) != "" {
+		ag, err := s.roster.Get(req.AgentID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		pinned = ag
+	}
+
+	session, err := s.createSession(pinned)
+	if err != nil {
+		log.Printf("Failed to create session: %v", err)
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	resp := createSessionResponse{SessionID: session.ID}
+	if session.router == nil {
+		resp.AgentID = session.current.Load().AgentID
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req messageRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		http.Error(w, "message is required", http.StatusBadRequest)
+		return
+	}
+
+	session, exists := s.getSession(req.SessionID)
+	if !exists {
+		http.Error(w, fmt.Sprintf("session '%s' not found", req.SessionID), http.StatusNotFound)
+		return
+	}
+
+	// The turn is abandoned if the client goes away
+	response, ag, err := session.send(r.Context(), req.Message)
+	if err != nil {
+		log.Printf("Session %s: %v", session.ID, err)
+		status := http.StatusBadGateway
+		if errors.Is(err, llmprotocol.ErrBudgetExceeded) {
+			status = http.StatusTooManyRequests
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, messageResponse{Response: response, Agent: ag.AgentID})
+}
+
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.URL.Query().Get("session_id")
+	session, exists := s.getSession(sessionID)
+	if !exists {
+		http.Error(w, fmt.Sprintf("session '%s' not found", sessionID), http.StatusNotFound)
+		return
+	}
+
+	ag := session.current.Load()
+	writeJSON(w, http.StatusOK, usageResponse{
+		SessionID: session.ID,
+		AgentID:   ag.AgentID,
+		Session:   session.usage(),
+		Agent:     ag.Usage(),
+	})
+}
+
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.URL.Query().Get("session_id")
+	session, exists := s.getSession(sessionID)
+	if !exists {
+		http.Error(w, fmt.Sprintf("session '%s' not found", sessionID), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, session.messages())
+}
+
+func (s *Server) handleBranches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.URL.Query().Get("session_id")
+	session, exists := s.getSession(sessionID)
+	if !exists {
+		http.Error(w, fmt.Sprintf("session '%s' not found", sessionID), http.StatusNotFound)
+		return
+	}
+
+	resp, _ := session.branches(nil)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleFork(w http.ResponseWriter, r *http.Request) {
+	session, req, ok := s.decodeBranchRequest(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := session.branches(func(c *chat.Chat) error {
+		_, err := c.Fork(req.Index)
+		return err
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleSwitch(w http.ResponseWriter, r *http.Request) {
+	session, req, ok := s.decodeBranchRequest(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := session.branches(func(c *chat.Chat) error {
+		return c.SwitchBranch(req.Branch)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleEdit replaces the user message at index on a new branch and runs
+// the turn again from there
+func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request) {
+	session, req, ok := s.decodeBranchRequest(w, r)
+	if !ok {
+		return
+	}
+
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		http.Error(w, "message is required", http.StatusBadRequest)
+		return
+	}
+
+	branch, response, ag, err := session.edit(r.Context(), req.Index, req.Message)
+	if errors.Is(err, errEditRejected) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Session %s: %v", session.ID, err)
+		status := http.StatusBadGateway
+		if errors.Is(err, llmprotocol.ErrBudgetExceeded) {
+			status = http.StatusTooManyRequests
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, messageResponse{Response: response, Agent: ag.AgentID, Branch: branch})
+}
+
+// decodeBranchRequest reads a POST body naming an existing session, writing
+// the error response itself when it can't
+func (s *Server) decodeBranchRequest(w http.ResponseWriter, r *http.Request) (*Session, branchRequest, bool) {
+	var req branchRequest
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, req, false
+	}
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, req, false
+	}
+
+	session, exists := s.getSession(req.SessionID)
+	if !exists {
+		http.Error(w, fmt.Sprintf("session '%s' not found", req.SessionID), http.StatusNotFound)
+		return nil, req, false
+	}
+	return session, req, true
+}
+
+// createSession starts a session with the pinned agent, or routes each of
+// its messages when pinned is nil and there is a router
+func (s *Server) createSession(pinned *agent.Agent) (*Session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	ag := pinned
+	if ag == nil {
+		ag = s.roster.Default
+	}
+
+	session := &Session{
+		ID:        id,
+		Chat:      ag.NewChat(id),
+		providers: make(map[string]transport.Provider),
+		events:    newEventHub(),
+	}
+	session.current.Store(ag)
+	if pinned == nil {
+		session.router = s.router
+	}
+
+	// Fail now rather than on the first message if the provider can't be built
+	if _, err := session.provider(ag); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.sessions[id] = session
+	s.mu.Unlock()
+
+	return session, nil
+}
+
+func (s *Server) getSession(sessionID string) (*Session, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, exists := s.sessions[strings.TrimSpace(sessionID)]
+	return session, exists
+}
+
+// usage returns the session's totals so far, including a running turn's calls
+func (sess *Session) usage() chat.Usage {
+	return sess.Chat.GetTotalUsage()
+}
+
+// messages returns a snapshot of the active branch's messages; a running
+// turn's messages appear as they are added
+func (sess *Session) messages() messagesResponse {
+	return messagesResponse{
+		SessionID: sess.ID,
+		Branch:    sess.Chat.CurrentBranch(),
+		Messages:  sess.Chat.GetMessages(),
+	}
+}
+
+// branches applies change, if any, to the chat between turns and describes
+// its branches afterwards
+func (sess *Session) branches(change func(c *chat.Chat) error) (branchesResponse, error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if change != nil {
+		if err := change(sess.Chat); err != nil {
+			return branchesResponse{}, err
+		}
+	}
+	return branchesResponse{
+		SessionID: sess.ID,
+		Active:    sess.Chat.CurrentBranch(),
+		Branches:  sess.Chat.ListBranches(),
+	}, nil
+}
+
+// errEditRejected wraps errors from an edit that never reached the model
+var errEditRejected = errors.New("edit rejected")
+
+// edit forks before the user message at index and sends message in its
+// place, returning the new branch, the reply and the agent that gave it
+func (sess *Session) edit(ctx context.Context, index int, message string) (string, string, *agent.Agent, error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	branch, err := sess.Chat.EditUserMessage(index)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("%w: %v", errEditRejected, err)
+	}
+
+	response, ag, err := sess.sendLocked(ctx, message)
+	return branch, response, ag, err
+}
+
+// send runs one turn and returns the final assistant text and the agent that
+// wrote it, publishing progress events to any connected WebSocket along the way
+func (sess *Session) send(ctx context.Context, message string) (string, *agent.Agent, error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.sendLocked(ctx, message)
+}
+
+// sendLocked is send for callers already holding sess.mu
+func (sess *Session) sendLocked(ctx context.Context, message string) (string, *agent.Agent, error) {
+	sess.events.publish(Event{Type: EventUserMessage, SessionID: sess.ID, Text: message})
+
+	ag := sess.current.Load()
+	if sess.router != nil {
+		routed, err := sess.router.Route(ctx, sess.Chat, message)
+		if err != nil {
+			sess.events.publish(Event{Type: EventError, SessionID: sess.ID, Error: err.Error()})
+			return "", nil, err
+		}
+		ag = routed
+		sess.current.Store(ag)
+		sess.events.publish(Event{Type: EventAgentSelected, SessionID: sess.ID, Agent: ag.AgentID})
+	}
+
+	provider, err := sess.provider(ag)
+	if err != nil {
+		sess.events.publish(Event{Type: EventError, SessionID: sess.ID, Error: err.Error()})
+		return "", nil, err
+	}
+
+	onEvent := func(event transport.StreamEvent) {
+		if event.Type == transport.StreamText {
+			sess.events.publish(Event{Type: EventAssistantDelta, SessionID: sess.ID, Text: event.Text})
+		}
+	}
+
+	if err := provider.SendRequestStream(ctx, sess.Chat, ag, message, onEvent); err != nil {
+		sess.events.publish(Event{Type: EventError, SessionID: sess.ID, Error: err.Error()})
+		return "", nil, err
+	}
+
+	response := ""
+	messages := sess.Chat.GetMessages()
+	if len(messages) > 0 && messages[len(messages)-1].Role == chat.RoleAssistant {
+		response = messages[len(messages)-1].Text()
+	}
+
+	sess.events.publish(Event{Type: EventTurnComplete, SessionID: sess.ID, Text: response, Agent: ag.AgentID})
+
+	return response, ag, nil
+}
+
+// provider returns the session's provider for ag, creating it on first use.
+// Callers hold sess.mu, except while the session is being created.
+func (sess *Session) provider(ag *agent.Agent) (transport.Provider, error) {
+	if provider, ok := sess.providers[ag.AgentID]; ok {
+		return provider, nil
+	}
+
+	provider, err := transport.NewProvider(ag.LLMConfig)
+	if err != nil {
+		return nil, err
+	}
+	sess.attachProviderEvents(provider)
+	sess.providers[ag.AgentID] = provider
+	return provider, nil
+}
+
+func (s *Server) withCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.allowOrigin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", s.allowOrigin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		}
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/chatstore"
+	"github.com/AnthonyL103/GOMCP/registry"
+)
+
+// newBudgetRoster returns a one-agent roster allowed to spend $1 a day. Its
+// API is never reached, since the budget check comes first.
+func newBudgetRoster() *agent.Roster {
+	ag := agent.NewAgent("helper", "Test agent", registry.NewRegistry(), &agent.LLMConfig{
+		APIKey:      "test",
+		Model:       "gpt-4o",
+		Temperature: 0.5,
+		MaxTokens:   100,
+		BaseURL:     "http://127.0.0.1:1",
+		MaxRetries:  -1,
+	}, false, false, false)
+	ag.Budget.MaxDailyCostUSD = 1
+	return &agent.Roster{Agents: []*agent.Agent{ag}, Default: ag}
+}
+
+func postJSON(t *testing.T, url string, body interface{}) *http.Response {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestDailyBudgetSurvivesRestart(t *testing.T) {
+	for _, backend := range []string{chatstore.BackendJSON, chatstore.BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store")
+
+			// An earlier run spends the whole budget
+			store, err := chatstore.Open(backend, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			earlier := newBudgetRoster()
+			if err := earlier.SetUsageStore(store); err != nil {
+				t.Fatal(err)
+			}
+			earlier.Default.RecordUsage(chat.Usage{Model: "gpt-4o", Calls: 1, CostUSD: 1.5})
+			store.Close()
+
+			// A restarted API server refuses the next message
+			store, err = chatstore.Open(backend, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+			roster := newBudgetRoster()
+			if err := roster.SetUsageStore(store); err != nil {
+				t.Fatal(err)
+			}
+			apiServer, err := NewServer(roster, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := httptest.NewServer(apiServer.Handler())
+			defer srv.Close()
+
+			resp := postJSON(t, srv.URL+"/api/chat/session", map[string]string{})
+			var session createSessionResponse
+			json.NewDecoder(resp.Body).Decode(&session)
+			resp.Body.Close()
+
+			resp = postJSON(t, srv.URL+"/api/chat/message", messageRequest{SessionID: session.SessionID, Message: "hi"})
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusTooManyRequests {
+				t.Fatalf("message after restart: status %d, want 429", resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/transport"
+)
+
+// Event types pushed over the session WebSocket
+const (
+	EventUserMessage    = "user_message"
+	EventAgentSelected  = "agent_selected" // the router picked the agent answering this turn
+	EventToolCallStart  = "tool_call_started"
+	EventToolResult     = "tool_result"
+	EventAssistantDelta = "assistant_text_delta"
+	EventTurnComplete   = "turn_complete"
+	EventError          = "error"
+)
+
+const (
+	subscriberBuffer = 64
+	writeTimeout     = 10 * time.Second
+)
+
+// Event is a single progress update for a chat session
+type Event struct {
+	Type       string           `json:"type"`
+	SessionID  string           `json:"session_id"`
+	Text       string           `json:"text,omitempty"`
+	Agent      string           `json:"agent,omitempty"`
+	ToolCall   *chat.ToolCall   `json:"tool_call,omitempty"`
+	ToolResult *chat.ToolResult `json:"tool_result,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	Timestamp  time.Time        `json:"timestamp"`
+}
+
+// eventHub fans session events out to every connected WebSocket
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[chan Event]struct{})}
+}
+
+func (h *eventHub) subscribe() chan Event {
+	ch := make(chan Event, subscriberBuffer)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *eventHub) unsubscribe(ch chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, exists := h.subscribers[ch]; exists {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publish never blocks the provider loop; subscribers that fall behind lose events
+func (h *eventHub) publish(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Dropping %s event for slow subscriber on session %s", event.Type, event.SessionID)
+		}
+	}
+}
+
+// handleEvents upgrades GET /api/chat/ws?session_id=... to a WebSocket and
+// streams the session's events until the client disconnects.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.URL.Query().Get("session_id")
+	session, exists := s.getSession(sessionID)
+	if !exists {
+		http.Error(w, fmt.Sprintf("session '%s' not found", sessionID), http.StatusNotFound)
+		return
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already wrote the HTTP error
+		log.Printf("WebSocket upgrade failed for session %s: %v", session.ID, err)
+		return
+	}
+	defer conn.Close()
+
+	events := session.events.subscribe()
+	defer session.events.unsubscribe(events)
+
+	// The client never sends anything we act on; reading only detects close
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// checkOrigin accepts WebSocket connections from the API's own origin (e.g.
+// through the Vite proxy) or the configured CORS origin. A "*" CORS origin
+// does not open the WebSocket to every site: the socket carries the session's
+// conversation, so it needs an exact match.
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if s.allowOrigin != "" && s.allowOrigin != "*" && strings.EqualFold(origin, s.allowOrigin) {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// attachProviderEvents routes the provider's tool callbacks into the session hub
+func (sess *Session) attachProviderEvents(provider transport.Provider) {
+	setter, ok := provider.(transport.ToolCallbackSetter)
+	if !ok {
+		return
+	}
+
+	setter.SetToolCallbacks(
+		func(call chat.ToolCall) {
+			sess.events.publish(Event{Type: EventToolCallStart, SessionID: sess.ID, ToolCall: &call})
+		},
+		func(call chat.ToolCall, result chat.ToolResult) {
+			sess.events.publish(Event{Type: EventToolResult, SessionID: sess.ID, ToolCall: &call, ToolResult: &result})
+		},
+	)
+}
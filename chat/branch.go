@@ -0,0 +1,198 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MainBranch is the branch every chat starts on
+const MainBranch = "main"
+
+// ErrInvalidForkPoint is returned when a fork would split a turn
+var ErrInvalidForkPoint = errors.New("can only fork before a user message or at the end of the chat")
+
+// Branch is one line of conversation in a chat's branch tree. The active
+// branch's messages and summary live in Chat.Messages and Chat.Summary; every
+// other branch keeps its own.
+type Branch struct {
+	ID        string    `json:"id"`
+	Parent    string    `json:"parent,omitempty"` // branch it was forked from, empty for main
+	ForkIndex int       `json:"fork_index"`       // messages it shared with Parent when forked
+	CreatedAt time.Time `json:"created_at"`
+	Messages  []Message `json:"messages,omitempty"` // nil while the branch is active
+	Summary   string    `json:"summary,omitempty"`  // empty while the branch is active
+}
+
+// BranchInfo describes a branch without its messages
+type BranchInfo struct {
+	ID           string    `json:"id"`
+	Parent       string    `json:"parent,omitempty"`
+	ForkIndex    int       `json:"fork_index"`
+	MessageCount int       `json:"message_count"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CurrentBranch returns the ID of the active branch
+func (c *Chat) CurrentBranch() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.currentBranch()
+}
+
+func (c *Chat) currentBranch() string {
+	if c.ActiveBranch == "" {
+		return MainBranch
+	}
+	return c.ActiveBranch
+}
+
+// ListBranches describes every branch, in the order they were created
+func (c *Chat) ListBranches() []BranchInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	branches := c.Branches
+	if len(branches) == 0 {
+		branches = []Branch{mainBranch(c.CreatedAt)}
+	}
+
+	infos := make([]BranchInfo, 0, len(branches))
+	for _, branch := range branches {
+		info := BranchInfo{
+			ID:           branch.ID,
+			Parent:       branch.Parent,
+			ForkIndex:    branch.ForkIndex,
+			MessageCount: len(branch.Messages),
+			CreatedAt:    branch.CreatedAt,
+		}
+		if branch.ID == c.currentBranch() {
+			info.Active = true
+			info.MessageCount = len(c.Messages)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Fork starts a new branch holding the active branch's first index messages
+// and switches to it; the messages after index stay on the old branch. index
+// must fall before a user message or at the end, so a turn is never split.
+// The new branch starts with the old one's summary, since the turns it
+// condenses come before both.
+func (c *Chat) Fork(index int) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fork(index)
+}
+
+func (c *Chat) fork(index int) (string, error) {
+	if index < 0 || index > len(c.Messages) {
+		return "", fmt.Errorf("fork index %d out of range (0-%d)", index, len(c.Messages))
+	}
+	if index < len(c.Messages) && c.Messages[index].Role != RoleUser {
+		return "", fmt.Errorf("%w: message %d has role %s", ErrInvalidForkPoint, index, c.Messages[index].Role)
+	}
+
+	c.initBranches()
+	parent := c.currentBranch()
+	c.stashActive()
+
+	id := c.newBranchID()
+	c.Branches = append(c.Branches, Branch{
+		ID:        id,
+		Parent:    parent,
+		ForkIndex: index,
+		CreatedAt: time.Now(),
+	})
+	c.ActiveBranch = id
+	c.Messages = append([]Message{}, c.Messages[:index]...)
+	c.touch()
+	c.save()
+	return id, nil
+}
+
+// EditUserMessage forks just before the user message at index, so sending
+// the edited text regenerates the conversation from there. The original
+// message and everything after it stay on the old branch.
+func (c *Chat) EditUserMessage(index int) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if index < 0 || index >= len(c.Messages) {
+		return "", fmt.Errorf("message index %d out of range (0-%d)", index, len(c.Messages)-1)
+	}
+	if c.Messages[index].Role != RoleUser {
+		return "", fmt.Errorf("message %d has role %s; only user messages can be edited", index, c.Messages[index].Role)
+	}
+	return c.fork(index)
+}
+
+// SwitchBranch makes the branch with id active
+func (c *Chat) SwitchBranch(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.initBranches()
+	if id == c.currentBranch() {
+		return nil
+	}
+
+	target := c.branchIndex(id)
+	if target < 0 {
+		return fmt.Errorf("branch %s not found", id)
+	}
+
+	c.stashActive()
+	c.Messages = c.Branches[target].Messages
+	if c.Messages == nil {
+		c.Messages = []Message{}
+	}
+	c.Summary = c.Branches[target].Summary
+	c.Branches[target].Messages = nil
+	c.Branches[target].Summary = ""
+	c.ActiveBranch = id
+	c.touch()
+	c.save()
+	return nil
+}
+
+// initBranches records the main branch for chats that have never forked
+func (c *Chat) initBranches() {
+	if len(c.Branches) == 0 {
+		c.Branches = []Branch{mainBranch(c.CreatedAt)}
+		c.ActiveBranch = MainBranch
+	}
+}
+
+func mainBranch(createdAt time.Time) Branch {
+	return Branch{ID: MainBranch, CreatedAt: createdAt}
+}
+
+// stashActive copies the active branch's messages and summary into its
+// Branch entry
+func (c *Chat) stashActive() {
+	if i := c.branchIndex(c.currentBranch()); i >= 0 {
+		c.Branches[i].Messages = append([]Message{}, c.Messages...)
+		c.Branches[i].Summary = c.Summary
+	}
+}
+
+func (c *Chat) branchIndex(id string) int {
+	for i, branch := range c.Branches {
+		if branch.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Chat) newBranchID() string {
+	for n := len(c.Branches); ; n++ {
+		id := fmt.Sprintf("branch-%d", n)
+		if c.branchIndex(id) < 0 {
+			return id
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package chat
+
+import "testing"
+
+func TestBranchesKeepTheirOwnSummary(t *testing.T) {
+	c := NewChat("branches", 0)
+	c.AddUserMessage("first")
+	c.AddAssistantMessage(TextBlock("reply"))
+	c.SetSummary("earlier turns")
+
+	forked, err := c.Fork(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetSummary(); got != "earlier turns" {
+		t.Fatalf("forked branch summary = %q, want the parent's", got)
+	}
+
+	c.SetSummary("forked summary")
+	if err := c.SwitchBranch(MainBranch); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetSummary(); got != "earlier turns" {
+		t.Fatalf("main summary = %q after switching back, want %q", got, "earlier turns")
+	}
+
+	if err := c.SwitchBranch(forked); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetSummary(); got != "forked summary" {
+		t.Fatalf("forked summary = %q after switching back, want %q", got, "forked summary")
+	}
+	if got := c.MessageCount(); got != 2 {
+		t.Fatalf("forked branch has %d messages, want 2", got)
+	}
+}
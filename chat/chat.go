@@ -1,21 +1,53 @@
 package chat
 
 import (
+    "strings"
+    "sync"
     "time"
 )
 
-// Message represents a single turn in the conversation
+// Message roles
+const (
+    RoleUser      = "user"
+    RoleAssistant = "assistant"
+    RoleTool      = "tool" // carries the tool_result blocks answering the previous assistant turn
+)
+
+// Content block types
+const (
+    BlockText       = "text"
+    BlockToolCall   = "tool_call"
+    BlockToolResult = "tool_result"
+    BlockImage      = "image"
+    BlockThinking   = "thinking"
+)
+
+// Message represents a single turn in the conversation as an ordered list of
+// content blocks, so text, tool calls and their results keep their original order
 type Message struct {
-    Role       string    `json:"role"`      // "user", "assistant"
-    Content    string    `json:"content"`   // Final response text
-    Timestamp  time.Time `json:"timestamp"`
-    
-    // Agent's complete turn (optional - only if tool was used)
-    ToolCall   *ToolCall   `json:"tool_call,omitempty"`   // What tool the agent called
-    ToolResult *ToolResult `json:"tool_result,omitempty"` // What the tool returned
+    Role      string         `json:"role"` // "user", "assistant", "tool"
+    Blocks    []ContentBlock `json:"blocks"`
+    Timestamp time.Time      `json:"timestamp"`
+    Usage     *Usage         `json:"usage,omitempty"` // tokens used by the LLM call that produced an assistant message
+}
+
+// ContentBlock is one piece of a message. Type decides which field is set.
+type ContentBlock struct {
+    Type string `json:"type"`
+
+    // Text holds the text of text and thinking blocks
+    Text string `json:"text,omitempty"`
+
+    // Signature is the provider's opaque signature for a thinking block (Gemini
+    // also signs text and tool call parts), which must be sent back unchanged
+    Signature string `json:"signature,omitempty"`
+
+    ToolCall   *ToolCall   `json:"tool_call,omitempty"`
+    ToolResult *ToolResult `json:"tool_result,omitempty"`
+    Image      *Image      `json:"image,omitempty"`
 }
 
-//no specific object declaration for params as these are stored in chat and past context must be sent 
+//no specific object declaration for params as these are stored in chat and past context must be sent
 //and stored in a specific format for different providers.
 
 type ToolCall struct {
@@ -23,7 +55,6 @@ type ToolCall struct {
     ToolID     string                 `json:"tool_id"`     // Matches your tool registry
     Handler    string                 `json:"handler"`
     Parameters map[string]interface{} `json:"parameters"`
-    Reasoning  string                 `json:"reasoning"`   // Agent's explanation
     ToolUseID  string                 `json:"tool_use_id"`
 }
 
@@ -35,20 +66,99 @@ type ToolResult struct {
     ToolUseID  string `json:"tool_use_id"`
 }
 
-// Chat stores the conversation history
+// Image is either inline base64 data with its media type, or a URL
+type Image struct {
+    MediaType string `json:"media_type,omitempty"` // e.g. "image/png"
+    Data      string `json:"data,omitempty"`       // base64 encoded
+    URL       string `json:"url,omitempty"`
+}
+
+func TextBlock(text string) ContentBlock {
+    return ContentBlock{Type: BlockText, Text: text}
+}
+
+func ThinkingBlock(text, signature string) ContentBlock {
+    return ContentBlock{Type: BlockThinking, Text: text, Signature: signature}
+}
+
+func ToolCallBlock(call ToolCall) ContentBlock {
+    return ContentBlock{Type: BlockToolCall, ToolCall: &call}
+}
+
+func ToolResultBlock(result ToolResult) ContentBlock {
+    return ContentBlock{Type: BlockToolResult, ToolResult: &result}
+}
+
+func ImageBlock(image Image) ContentBlock {
+    return ContentBlock{Type: BlockImage, Image: &image}
+}
+
+// Text joins the message's text blocks
+func (m Message) Text() string {
+    parts := []string{}
+    for _, block := range m.Blocks {
+        if block.Type == BlockText && block.Text != "" {
+            parts = append(parts, block.Text)
+        }
+    }
+    return strings.Join(parts, "\n\n")
+}
+
+// ToolCalls returns the message's tool calls in order
+func (m Message) ToolCalls() []ToolCall {
+    calls := []ToolCall{}
+    for _, block := range m.Blocks {
+        if block.Type == BlockToolCall && block.ToolCall != nil {
+            calls = append(calls, *block.ToolCall)
+        }
+    }
+    return calls
+}
+
+// ToolResults returns the message's tool results in order
+func (m Message) ToolResults() []ToolResult {
+    results := []ToolResult{}
+    for _, block := range m.Blocks {
+        if block.Type == BlockToolResult && block.ToolResult != nil {
+            results = append(results, *block.ToolResult)
+        }
+    }
+    return results
+}
+
+// Chat stores the conversation history. Its methods are safe to call from
+// several goroutines; the exported fields are there for serialization and
+// must not be touched directly once the chat is shared.
 type Chat struct {
     ChatID      string    `json:"chat_id"`
     Messages    []Message `json:"messages"`
     MaxMessages int       `json:"max_messages"` // Sliding window (0 = unlimited)
     CreatedAt   time.Time `json:"created_at"`
     UpdatedAt   time.Time `json:"updated_at"`
+    // TotalUsage covers every LLM call in the session, including ones whose
+    // messages have been trimmed
+    TotalUsage Usage `json:"total_usage"`
+    // Summary condenses the turns dropped from Messages when the agent
+    // summarizes history instead of trimming it. Like Messages, it belongs
+    // to the active branch.
+    Summary string `json:"summary,omitempty"`
+    // ActiveBranch names the branch Messages belongs to; Branches holds the
+    // branch tree, and is empty until the chat is first forked
+    ActiveBranch string   `json:"active_branch,omitempty"`
+    Branches     []Branch `json:"branches,omitempty"`
+
+    // store, when set, receives every change to the chat
+    store ChatStore
+
+    mu      sync.RWMutex
+    changed chan struct{} // closed and replaced on every change, see Changed
 }
 
 func NewChat(chatID string, maxMessages int) *Chat {
     if maxMessages < 0 {
         maxMessages = 0 // 0 = no limit
     }
-    
+
     return &Chat{
         ChatID:      chatID,
         Messages:    []Message{},
@@ -59,51 +169,182 @@ func NewChat(chatID string, maxMessages int) *Chat {
 }
 
 func (c *Chat) AddUserMessage(content string) {
-    c.Messages = append(c.Messages, Message{
-        Role:      "user",
-        Content:   content,
+    c.AddMessage(RoleUser, TextBlock(content))
+}
+
+// AddAssistantMessage adds a complete agent turn: any thinking, text and
+// tool call blocks in the order the model produced them
+func (c *Chat) AddAssistantMessage(blocks ...ContentBlock) {
+    c.AddMessage(RoleAssistant, blocks...)
+}
+
+// AddAssistantReply is AddAssistantMessage for a reply whose LLM call usage is known
+func (c *Chat) AddAssistantReply(usage *Usage, blocks ...ContentBlock) {
+    c.appendMessage(Message{
+        Role:      RoleAssistant,
+        Blocks:    blocks,
         Timestamp: time.Now(),
+        Usage:     usage,
     })
-    c.UpdatedAt = time.Now()
-    c.trimIfNeeded()
-}
-
-// AddAssistantMessage adds a complete agent turn
-// If the agent used a tool, include toolCall and toolResult
-func (c *Chat) AddAssistantMessage(content string, toolCall *ToolCall, toolResult *ToolResult) {
-    c.Messages = append(c.Messages, Message{
-        Role:       "assistant",
-        Content:    content,
-        ToolCall:   toolCall,
-        ToolResult: toolResult,
-        Timestamp:  time.Now(),
+}
+
+// RecordUsage adds one LLM call to TotalUsage
+func (c *Chat) RecordUsage(usage Usage) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    c.TotalUsage.Add(usage)
+    c.touch()
+}
+
+// GetTotalUsage returns the usage of every LLM call in the session
+func (c *Chat) GetTotalUsage() Usage {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.TotalUsage
+}
+
+// GetSummary returns the summary of the turns dropped from the history
+func (c *Chat) GetSummary() string {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.Summary
+}
+
+// SetSummary replaces the summary of the turns dropped from the history
+func (c *Chat) SetSummary(summary string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    c.Summary = summary
+    c.touch()
+    c.save()
+}
+
+// AddToolResults adds the results answering the previous assistant turn's tool calls
+func (c *Chat) AddToolResults(results ...ToolResult) {
+    blocks := make([]ContentBlock, 0, len(results))
+    for _, result := range results {
+        blocks = append(blocks, ToolResultBlock(result))
+    }
+    c.AddMessage(RoleTool, blocks...)
+}
+
+func (c *Chat) AddMessage(role string, blocks ...ContentBlock) {
+    c.appendMessage(Message{
+        Role:      role,
+        Blocks:    blocks,
+        Timestamp: time.Now(),
     })
-    c.UpdatedAt = time.Now()
-    c.trimIfNeeded()
 }
 
+// appendMessage adds msg and saves it, or saves the whole chat if adding it
+// trimmed older messages
+func (c *Chat) appendMessage(msg Message) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    c.Messages = append(c.Messages, msg)
+    c.touch()
+
+    if c.trimIfNeeded() {
+        c.save()
+    } else {
+        c.saveMessage(msg)
+    }
+}
+
+// GetMessages returns a snapshot of the history. Messages are never changed
+// once added, so the copy stays valid while the chat moves on.
 func (c *Chat) GetMessages() []Message {
-    return c.Messages
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return append([]Message{}, c.Messages...)
 }
 
+// Clear drops the history and its summary; TotalUsage is kept since those
+// calls were still made
 func (c *Chat) Clear() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
     c.Messages = []Message{}
-    c.UpdatedAt = time.Now()
+    c.Summary = ""
+    c.touch()
+    c.save()
+}
+
+// DropLatestTurn removes the last user message and everything after it,
+// e.g. to undo a turn that failed part way through
+func (c *Chat) DropLatestTurn() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    start := c.lastTurnStart()
+    if len(c.Messages) == 0 || c.Messages[start].Role != RoleUser {
+        return
+    }
+    c.Messages = append([]Message{}, c.Messages[:start]...)
+    c.touch()
+    c.save()
 }
 
 func (c *Chat) MessageCount() int {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
     return len(c.Messages)
 }
 
-func (c *Chat) trimIfNeeded() {
-    if c.MaxMessages > 0 && len(c.Messages) > c.MaxMessages {
-        c.Messages = c.Messages[len(c.Messages)-c.MaxMessages:]
+// Changed returns a channel that is closed the next time the chat changes,
+// so a watcher can wait for new messages instead of polling. Take the
+// channel before reading the chat to be sure no change is missed.
+func (c *Chat) Changed() <-chan struct{} {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if c.changed == nil {
+        c.changed = make(chan struct{})
     }
+    return c.changed
 }
 
+// touch marks the chat updated and wakes everyone waiting on Changed. The
+// caller holds c.mu.
+func (c *Chat) touch() {
+    c.UpdatedAt = time.Now()
+    if c.changed != nil {
+        close(c.changed)
+        c.changed = nil
+    }
+}
+
+// trimIfNeeded drops the oldest messages past MaxMessages. The window always
+// starts at a user message so it never opens with orphaned tool results; if
+// the latest turn alone is longer than MaxMessages it is kept whole. Reports
+// whether anything was dropped.
+func (c *Chat) trimIfNeeded() bool {
+    if c.MaxMessages <= 0 || len(c.Messages) <= c.MaxMessages {
+        return false
+    }
+
+    start := c.lastTurnStart()
+    for i := len(c.Messages) - c.MaxMessages; i < start; i++ {
+        if c.Messages[i].Role == RoleUser {
+            start = i
+            break
+        }
+    }
+    c.dropOldest(start)
+    return start > 0
+}
+
+// GetRecentMessages returns a snapshot of the last n messages
 func (c *Chat) GetRecentMessages(n int) []Message {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
     if n <= 0 || n >= len(c.Messages) {
-        return c.Messages
+        return append([]Message{}, c.Messages...)
     }
-    return c.Messages[len(c.Messages)-n:]
-}
\ No newline at end of file
+    return append([]Message{}, c.Messages[len(c.Messages)-n:]...)
+}
@@ -0,0 +1,57 @@
+package chat
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Run with -race: writers append while readers take snapshots
+func TestChatConcurrentAccess(t *testing.T) {
+	c := NewChat("concurrent", 0)
+
+	const writers, perWriter = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				c.AddUserMessage(fmt.Sprintf("writer %d message %d", w, i))
+				c.RecordUsage(Usage{InputTokens: 1})
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				msgs := c.GetMessages()
+				for _, msg := range msgs {
+					_ = msg.Text()
+				}
+				_ = c.GetRecentMessages(5)
+				_ = c.MessageCount()
+				_ = c.GetTotalUsage()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := c.MessageCount(); got != writers*perWriter {
+		t.Fatalf("MessageCount() = %d, want %d", got, writers*perWriter)
+	}
+	if got := c.GetTotalUsage().InputTokens; got != writers*perWriter {
+		t.Fatalf("InputTokens = %d, want %d", got, writers*perWriter)
+	}
+}
+
+func TestGetMessagesReturnsCopy(t *testing.T) {
+	c := NewChat("copy", 0)
+	c.AddUserMessage("original")
+
+	msgs := c.GetMessages()
+	msgs[0] = Message{Role: RoleUser, Blocks: []ContentBlock{TextBlock("changed")}}
+
+	if got := c.GetMessages()[0].Text(); got != "original" {
+		t.Fatalf("chat message changed through snapshot: %q", got)
+	}
+}
@@ -0,0 +1,229 @@
+package chat
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// JSONL record types. An export is one chat record followed by a message
+// record per message, in order.
+const (
+	recordChat    = "chat"
+	recordMessage = "message"
+)
+
+// maxJSONLLine bounds one record, which may hold a large tool result or image
+const maxJSONLLine = 64 << 20
+
+type jsonlChat struct {
+	Type        string    `json:"type"`
+	ChatID      string    `json:"chat_id"`
+	MaxMessages int       `json:"max_messages"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	TotalUsage  Usage     `json:"total_usage"`
+	Summary     string    `json:"summary,omitempty"`
+
+	ActiveBranch string   `json:"active_branch,omitempty"`
+	Branches     []Branch `json:"branches,omitempty"` // inactive branches carry their own messages
+}
+
+type jsonlMessage struct {
+	Type string `json:"type"`
+	Message
+}
+
+// WriteJSONL exports the chat as JSON Lines: a chat record, then one record
+// per message of the active branch with every block, tool call ID and usage
+// kept as stored. Other branches are part of the chat record.
+func (c *Chat) WriteJSONL(w io.Writer) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	enc := json.NewEncoder(w)
+
+	header := jsonlChat{
+		Type:        recordChat,
+		ChatID:      c.ChatID,
+		MaxMessages: c.MaxMessages,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+		TotalUsage:  c.TotalUsage,
+		Summary:     c.Summary,
+
+		ActiveBranch: c.ActiveBranch,
+		Branches:     c.Branches,
+	}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+
+	for _, msg := range c.Messages {
+		if err := enc.Encode(jsonlMessage{Type: recordMessage, Message: msg}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadJSONL rebuilds a chat from WriteJSONL output. The chat record may be
+// left out of hand-written files, in which case the chat is named "imported".
+// Every tool result must answer a tool call earlier in the file.
+func ReadJSONL(r io.Reader) (*Chat, error) {
+	c := NewChat("imported", 0)
+	callIDs := map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLine)
+
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+
+		var record struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		switch record.Type {
+		case recordChat:
+			if line != 1 {
+				return nil, fmt.Errorf("line %d: the chat record must come first", line)
+			}
+			var header jsonlChat
+			if err := json.Unmarshal(data, &header); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			c.ChatID = header.ChatID
+			c.MaxMessages = header.MaxMessages
+			c.CreatedAt = header.CreatedAt
+			c.UpdatedAt = header.UpdatedAt
+			c.TotalUsage = header.TotalUsage
+			c.Summary = header.Summary
+			c.ActiveBranch = header.ActiveBranch
+			c.Branches = header.Branches
+
+		case recordMessage:
+			var record jsonlMessage
+			if err := json.Unmarshal(data, &record); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			msg := record.Message
+			if msg.Blocks == nil {
+				msg.Blocks = []ContentBlock{}
+			}
+
+			for _, call := range msg.ToolCalls() {
+				callIDs[call.ToolUseID] = true
+			}
+			for _, result := range msg.ToolResults() {
+				if !callIDs[result.ToolUseID] {
+					return nil, fmt.Errorf("line %d: tool result %q has no matching tool call", line, result.ToolUseID)
+				}
+			}
+			c.Messages = append(c.Messages, msg)
+
+		default:
+			return nil, fmt.Errorf("line %d: unknown record type %q", line, record.Type)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if c.ChatID == "" {
+		return nil, errors.New("chat record has no chat_id")
+	}
+	return c, nil
+}
+
+// WriteMarkdown renders the chat for people to read, e.g. in a bug report.
+// Thinking, tool calls and tool results are collapsible <details> sections.
+func (c *Chat) WriteMarkdown(w io.Writer) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Chat %s\n\n", c.ChatID)
+	if len(c.Branches) > 1 {
+		fmt.Fprintf(&b, "_Branch %s of %d_  \n", c.currentBranch(), len(c.Branches))
+	}
+	fmt.Fprintf(&b, "_%d messages, created %s, updated %s_  \n", len(c.Messages), c.CreatedAt.Format(time.RFC3339), c.UpdatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "_Usage: %s_\n\n", c.TotalUsage)
+
+	if c.Summary != "" {
+		b.WriteString("<details>\n<summary>Summary of earlier conversation</summary>\n\n")
+		b.WriteString(c.Summary)
+		b.WriteString("\n\n</details>\n\n")
+	}
+
+	for _, msg := range c.Messages {
+		switch msg.Role {
+		case RoleUser:
+			b.WriteString("## User\n\n")
+		case RoleAssistant:
+			b.WriteString("## Assistant\n\n")
+		}
+
+		for _, block := range msg.Blocks {
+			writeMarkdownBlock(&b, block)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownBlock(b *strings.Builder, block ContentBlock) {
+	switch block.Type {
+	case BlockText:
+		if block.Text != "" {
+			b.WriteString(block.Text + "\n\n")
+		}
+	case BlockThinking:
+		writeDetails(b, "Thinking", "", block.Text)
+	case BlockToolCall:
+		if block.ToolCall != nil {
+			params, _ := json.MarshalIndent(block.ToolCall.Parameters, "", "  ")
+			title := fmt.Sprintf("Tool call: <code>%s</code> (%s)", html.EscapeString(block.ToolCall.ToolID), html.EscapeString(block.ToolCall.ToolUseID))
+			writeDetails(b, title, "json", string(params))
+		}
+	case BlockToolResult:
+		if block.ToolResult != nil {
+			title := fmt.Sprintf("Tool result: <code>%s</code> (%s)", html.EscapeString(block.ToolResult.ToolID), html.EscapeString(block.ToolResult.ToolUseID))
+			if block.ToolResult.IsError {
+				title += " — error"
+			}
+			writeDetails(b, title, "", block.ToolResult.Content)
+		}
+	case BlockImage:
+		if block.Image == nil {
+			return
+		}
+		if block.Image.URL != "" {
+			fmt.Fprintf(b, "![image](%s)\n\n", block.Image.URL)
+		} else {
+			fmt.Fprintf(b, "_[%s image, %d bytes base64]_\n\n", block.Image.MediaType, len(block.Image.Data))
+		}
+	}
+}
+
+// writeDetails writes a collapsed section holding body as a code block
+func writeDetails(b *strings.Builder, title, lang, body string) {
+	fence := "```"
+	for strings.Contains(body, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "<details>\n<summary>%s</summary>\n\n%s%s\n%s\n%s\n\n</details>\n\n", title, fence, lang, body, fence)
+}
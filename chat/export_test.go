@@ -0,0 +1,25 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteMarkdownEscapesToolIDs(t *testing.T) {
+	c := NewChat("markdown", 0)
+	c.AddUserMessage("hi")
+	c.AddAssistantMessage(ToolCallBlock(ToolCall{ToolID: "<b>bold</b>", ToolUseID: "call_<1>"}))
+	c.AddToolResults(ToolResult{ToolID: "<b>bold</b>", ToolUseID: "call_<1>", Content: "ok"})
+
+	var b strings.Builder
+	if err := c.WriteMarkdown(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if strings.Contains(out, "<b>") || strings.Contains(out, "call_<1>") {
+		t.Fatalf("tool IDs not escaped:\n%s", out)
+	}
+	if !strings.Contains(out, "<code>&lt;b&gt;bold&lt;/b&gt;</code> (call_&lt;1&gt;)") {
+		t.Fatalf("escaped tool call title missing:\n%s", out)
+	}
+}
@@ -0,0 +1,158 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// legacyMessage is the message shape used before content blocks: a text
+// Content plus at most one tool call and its result
+type legacyMessage struct {
+	Role       string          `json:"role"`
+	Content    string          `json:"content"`
+	Timestamp  time.Time       `json:"timestamp"`
+	ToolCall   *legacyToolCall `json:"tool_call,omitempty"`
+	ToolResult *ToolResult     `json:"tool_result,omitempty"`
+}
+
+type legacyToolCall struct {
+	ToolCall
+	Reasoning string `json:"reasoning"`
+}
+
+// chatJSON mirrors Chat with raw messages so each one can be checked for the legacy shape
+type chatJSON struct {
+	ChatID      string            `json:"chat_id"`
+	Messages    []json.RawMessage `json:"messages"`
+	MaxMessages int               `json:"max_messages"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	TotalUsage  Usage             `json:"total_usage"`
+	Summary     string            `json:"summary,omitempty"`
+
+	ActiveBranch string   `json:"active_branch,omitempty"`
+	Branches     []Branch `json:"branches,omitempty"`
+}
+
+// UnmarshalJSON reads both the block-based format and the legacy one,
+// converting legacy messages to blocks on the fly
+func (c *Chat) UnmarshalJSON(data []byte) error {
+	var raw chatJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	messages, err := decodeMessages(raw.Messages)
+	if err != nil {
+		return err
+	}
+
+	c.ChatID = raw.ChatID
+	c.Messages = messages
+	c.MaxMessages = raw.MaxMessages
+	c.CreatedAt = raw.CreatedAt
+	c.UpdatedAt = raw.UpdatedAt
+	c.TotalUsage = raw.TotalUsage
+	c.Summary = raw.Summary
+	c.ActiveBranch = raw.ActiveBranch
+	c.Branches = raw.Branches
+	return nil
+}
+
+func decodeMessages(rawMessages []json.RawMessage) ([]Message, error) {
+	messages := []Message{}
+	legacy := []legacyMessage{}
+
+	flushLegacy := func() {
+		if len(legacy) > 0 {
+			messages = append(messages, migrateLegacyMessages(legacy)...)
+			legacy = legacy[:0]
+		}
+	}
+
+	for i, rawMessage := range rawMessages {
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(rawMessage, &keys); err != nil {
+			return nil, fmt.Errorf("invalid message %d: %w", i, err)
+		}
+
+		if _, isBlockFormat := keys["blocks"]; isBlockFormat {
+			flushLegacy()
+			var msg Message
+			if err := json.Unmarshal(rawMessage, &msg); err != nil {
+				return nil, fmt.Errorf("invalid message %d: %w", i, err)
+			}
+			messages = append(messages, msg)
+			continue
+		}
+
+		var old legacyMessage
+		if err := json.Unmarshal(rawMessage, &old); err != nil {
+			return nil, fmt.Errorf("invalid legacy message %d: %w", i, err)
+		}
+		legacy = append(legacy, old)
+	}
+	flushLegacy()
+
+	return messages, nil
+}
+
+// migrateLegacyMessages converts legacy messages to blocks. Consecutive tool
+// cycles from one turn (only the first carrying reasoning, only the last
+// carrying reply text) become one assistant message with every tool call and
+// one tool message with every result.
+func migrateLegacyMessages(legacy []legacyMessage) []Message {
+	messages := []Message{}
+
+	for i := 0; i < len(legacy); i++ {
+		msg := legacy[i]
+
+		if msg.ToolCall == nil || msg.ToolResult == nil {
+			if msg.Role != RoleUser && msg.Content == "" {
+				continue
+			}
+			messages = append(messages, Message{
+				Role:      msg.Role,
+				Blocks:    []ContentBlock{TextBlock(msg.Content)},
+				Timestamp: msg.Timestamp,
+			})
+			continue
+		}
+
+		group := []legacyMessage{msg}
+		for i+1 < len(legacy) && group[len(group)-1].Content == "" {
+			next := legacy[i+1]
+			if next.ToolCall == nil || next.ToolResult == nil || next.ToolCall.Reasoning != "" {
+				break
+			}
+			group = append(group, next)
+			i++
+		}
+
+		assistantBlocks := []ContentBlock{}
+		if reasoning := group[0].ToolCall.Reasoning; reasoning != "" {
+			assistantBlocks = append(assistantBlocks, TextBlock(reasoning))
+		}
+		resultBlocks := []ContentBlock{}
+		for _, cycle := range group {
+			assistantBlocks = append(assistantBlocks, ToolCallBlock(cycle.ToolCall.ToolCall))
+			resultBlocks = append(resultBlocks, ToolResultBlock(*cycle.ToolResult))
+		}
+
+		messages = append(messages,
+			Message{Role: RoleAssistant, Blocks: assistantBlocks, Timestamp: msg.Timestamp},
+			Message{Role: RoleTool, Blocks: resultBlocks, Timestamp: msg.Timestamp},
+		)
+
+		if last := group[len(group)-1]; last.Content != "" {
+			messages = append(messages, Message{
+				Role:      RoleAssistant,
+				Blocks:    []ContentBlock{TextBlock(last.Content)},
+				Timestamp: last.Timestamp,
+			})
+		}
+	}
+
+	return messages
+}
@@ -0,0 +1,67 @@
+package chat
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestLegacyChatMigratesToBlocks(t *testing.T) {
+	data, err := os.ReadFile("testdata/legacy_chat.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Chat
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	// The two tool cycles of the first turn become one assistant message
+	// with both calls, one tool message with both results, and the reply
+	want := []struct {
+		role   string
+		blocks []string
+	}{
+		{RoleUser, []string{BlockText}},
+		{RoleAssistant, []string{BlockText, BlockToolCall, BlockToolCall}},
+		{RoleTool, []string{BlockToolResult, BlockToolResult}},
+		{RoleAssistant, []string{BlockText}},
+		{RoleUser, []string{BlockText}},
+		{RoleAssistant, []string{BlockText}},
+	}
+	if len(c.Messages) != len(want) {
+		t.Fatalf("migrated to %d messages, want %d: %+v", len(c.Messages), len(want), c.Messages)
+	}
+	for i, msg := range c.Messages {
+		if msg.Role != want[i].role || len(msg.Blocks) != len(want[i].blocks) {
+			t.Fatalf("message %d = %s with %d blocks, want %s with %d", i, msg.Role, len(msg.Blocks), want[i].role, len(want[i].blocks))
+		}
+		for j, block := range msg.Blocks {
+			if block.Type != want[i].blocks[j] {
+				t.Fatalf("message %d block %d = %s, want %s", i, j, block.Type, want[i].blocks[j])
+			}
+		}
+	}
+
+	if got := c.Messages[1].Blocks[0].Text; got != "I'll check both cities." {
+		t.Fatalf("reasoning = %q, want it kept as text before the calls", got)
+	}
+	if got := c.Messages[3].Text(); got != "Paris is 18°C and sunny; London is 14°C and cloudy." {
+		t.Fatalf("reply = %q", got)
+	}
+
+	// Every call is answered, in order, by the result with its ID
+	calls := c.Messages[1].ToolCalls()
+	results := c.Messages[2].ToolResults()
+	for i, call := range calls {
+		if results[i].ToolUseID != call.ToolUseID {
+			t.Fatalf("result %d answers %q, want %q", i, results[i].ToolUseID, call.ToolUseID)
+		}
+	}
+	if calls[0].Parameters["city"] != "Paris" || calls[1].Parameters["city"] != "London" {
+		t.Fatalf("call parameters = %v, %v", calls[0].Parameters, calls[1].Parameters)
+	}
+	if results[1].Content != "14°C, cloudy" {
+		t.Fatalf("second result = %q", results[1].Content)
+	}
+}
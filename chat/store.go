@@ -0,0 +1,66 @@
+package chat
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrChatNotFound is returned by a ChatStore for a chat it doesn't hold
+var ErrChatNotFound = errors.New("chat not found")
+
+// ChatStore persists chats between runs. A chat attached with SetStore saves
+// each message as it is added, and saves itself in full when messages are
+// dropped. The chat is locked while it calls its store, so implementations
+// read its fields directly rather than through its methods.
+type ChatStore interface {
+	// AppendMessage saves msg, just appended to c, along with c's usage,
+	// summary and timestamps
+	AppendMessage(c *Chat, msg Message) error
+	// Save replaces the stored copy of c
+	Save(c *Chat) error
+	// Load returns the stored chat, or ErrChatNotFound
+	Load(chatID string) (*Chat, error)
+	// List describes every stored chat, most recently updated first
+	List() ([]ChatInfo, error)
+	// Delete removes the chat, or returns ErrChatNotFound
+	Delete(chatID string) error
+}
+
+// ChatInfo describes a stored chat without its messages
+type ChatInfo struct {
+	ChatID       string    `json:"chat_id"`
+	MessageCount int       `json:"message_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SetStore attaches the chat to store and saves it there in full
+func (c *Chat) SetStore(store ChatStore) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.store = store
+	return store.Save(c)
+}
+
+// save writes the whole chat to its store, if it has one. Store failures are
+// logged rather than failing the turn that caused them.
+func (c *Chat) save() {
+	if c.store == nil {
+		return
+	}
+	if err := c.store.Save(c); err != nil {
+		log.Printf("Failed to save chat %s: %v", c.ChatID, err)
+	}
+}
+
+// saveMessage appends msg to the chat's store, if it has one
+func (c *Chat) saveMessage(msg Message) {
+	if c.store == nil {
+		return
+	}
+	if err := c.store.AppendMessage(c, msg); err != nil {
+		log.Printf("Failed to save message to chat %s: %v", c.ChatID, err)
+	}
+}
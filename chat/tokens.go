@@ -0,0 +1,128 @@
+package chat
+
+import "encoding/json"
+
+// Token estimates are deliberately rough: about four characters per token
+// for English text and JSON, which errs on the high side for most models
+const (
+	charsPerToken   = 4
+	messageOverhead = 4    // role and framing added to every message
+	imageTokens     = 1600 // a typical image after provider downscaling
+)
+
+// EstimateTextTokens estimates the tokens text encodes to
+func EstimateTextTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return len(text)/charsPerToken + 1
+}
+
+// EstimateTokens estimates the prompt tokens the message takes up
+func (m Message) EstimateTokens() int {
+	tokens := messageOverhead
+	for _, block := range m.Blocks {
+		switch block.Type {
+		case BlockText, BlockThinking:
+			tokens += EstimateTextTokens(block.Text)
+		case BlockToolCall:
+			if block.ToolCall != nil {
+				params, _ := json.Marshal(block.ToolCall.Parameters)
+				tokens += EstimateTextTokens(block.ToolCall.ToolID) + EstimateTextTokens(string(params))
+			}
+		case BlockToolResult:
+			if block.ToolResult != nil {
+				tokens += EstimateTextTokens(block.ToolResult.Content)
+			}
+		case BlockImage:
+			tokens += imageTokens
+		}
+	}
+	return tokens
+}
+
+// EstimateTokens estimates the prompt tokens the whole history takes up
+func (c *Chat) EstimateTokens() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.estimateTokens()
+}
+
+func (c *Chat) estimateTokens() int {
+	tokens := 0
+	for _, msg := range c.Messages {
+		tokens += msg.EstimateTokens()
+	}
+	return tokens
+}
+
+// TrimPoint returns how many of the oldest messages must go for the rest to
+// fit in maxTokens. It only cuts before a user message, so a tool call is
+// never separated from its result, and never drops the latest turn even if
+// that alone is over the limit. 0 means nothing needs to go.
+func (c *Chat) TrimPoint(maxTokens int) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.trimPoint(maxTokens)
+}
+
+func (c *Chat) trimPoint(maxTokens int) int {
+	total := c.estimateTokens()
+	if maxTokens <= 0 || total <= maxTokens {
+		return 0
+	}
+
+	last := c.lastTurnStart()
+	for i, msg := range c.Messages[:last] {
+		total -= msg.EstimateTokens()
+		if total <= maxTokens && c.Messages[i+1].Role == RoleUser {
+			return i + 1
+		}
+	}
+	return last
+}
+
+// TrimToTokens drops the oldest turns until the history fits in maxTokens
+func (c *Chat) TrimToTokens(maxTokens int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n := c.trimPoint(maxTokens); n > 0 {
+		c.dropOldest(n)
+		c.save()
+	}
+}
+
+// DropOldest removes the n oldest messages
+func (c *Chat) DropOldest(n int) {
+	if n <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.dropOldest(n)
+	c.save()
+}
+
+func (c *Chat) dropOldest(n int) {
+	if n <= 0 {
+		return
+	}
+	if n > len(c.Messages) {
+		n = len(c.Messages)
+	}
+	c.Messages = append([]Message{}, c.Messages[n:]...)
+	c.touch()
+}
+
+// lastTurnStart is the index of the last user message, or 0 if there is none
+func (c *Chat) lastTurnStart() int {
+	for i := len(c.Messages) - 1; i > 0; i-- {
+		if c.Messages[i].Role == RoleUser {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+// toolTurnChat holds three turns; the first two run a tool with a large result
+func toolTurnChat() *Chat {
+	c := NewChat("tokens", 0)
+	for i, city := range []string{"Paris", "London"} {
+		useID := "call_" + city
+		c.AddUserMessage("Weather in " + city + "?")
+		c.AddAssistantMessage(TextBlock("Checking."), ToolCallBlock(ToolCall{ToolID: "get_weather", ToolUseID: useID, Parameters: map[string]interface{}{"city": city}}))
+		c.AddToolResults(ToolResult{ToolID: "get_weather", ToolUseID: useID, Content: strings.Repeat("sunny ", 200*(i+1))})
+		c.AddAssistantMessage(TextBlock("It's sunny in " + city + "."))
+	}
+	c.AddUserMessage("Thanks!")
+	return c
+}
+
+func TestTrimPointKeepsToolCallsWithResults(t *testing.T) {
+	c := toolTurnChat()
+	total := c.EstimateTokens()
+
+	for limit := 1; limit <= total; limit++ {
+		n := c.TrimPoint(limit)
+		if n == 0 {
+			continue
+		}
+		if role := c.Messages[n].Role; role != RoleUser {
+			t.Fatalf("TrimPoint(%d) = %d, which starts the history at a %s message", limit, n, role)
+		}
+
+		// Every result left must still have its call
+		calls := map[string]bool{}
+		for _, msg := range c.Messages[n:] {
+			for _, call := range msg.ToolCalls() {
+				calls[call.ToolUseID] = true
+			}
+			for _, result := range msg.ToolResults() {
+				if !calls[result.ToolUseID] {
+					t.Fatalf("TrimPoint(%d) = %d separates result %s from its call", limit, n, result.ToolUseID)
+				}
+			}
+		}
+	}
+
+	if n := c.TrimPoint(total); n != 0 {
+		t.Fatalf("TrimPoint(total) = %d, want 0", n)
+	}
+	if n, last := c.TrimPoint(1), len(c.Messages)-1; n != last {
+		t.Fatalf("TrimPoint(1) = %d, want the latest turn at %d kept", n, last)
+	}
+}
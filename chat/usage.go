@@ -0,0 +1,48 @@
+package chat
+
+import "fmt"
+
+// Usage counts the tokens LLM calls used and what they cost. InputTokens
+// excludes cached input, which is counted in CacheReadTokens.
+type Usage struct {
+	Model            string  `json:"model,omitempty"` // empty when summing calls to different models
+	Calls            int     `json:"calls"`
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	CacheReadTokens  int     `json:"cache_read_tokens"`
+	CacheWriteTokens int     `json:"cache_write_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	// Unpriced is set when a call's model had no price, so CostUSD is a lower bound
+	Unpriced bool `json:"unpriced,omitempty"`
+}
+
+// Add sums other into u
+func (u *Usage) Add(other Usage) {
+	if u.Calls == 0 {
+		u.Model = other.Model
+	} else if u.Model != other.Model {
+		u.Model = ""
+	}
+
+	u.Calls += other.Calls
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.CacheWriteTokens += other.CacheWriteTokens
+	u.CostUSD += other.CostUSD
+	u.Unpriced = u.Unpriced || other.Unpriced
+}
+
+// TotalTokens is every input, cached and output token
+func (u Usage) TotalTokens() int {
+	return u.InputTokens + u.CacheReadTokens + u.CacheWriteTokens + u.OutputTokens
+}
+
+func (u Usage) String() string {
+	cost := fmt.Sprintf("$%.4f", u.CostUSD)
+	if u.Unpriced {
+		cost += "+ (some models unpriced)"
+	}
+	return fmt.Sprintf("%d calls, %d input, %d output, %d cache read, %d cache write tokens, %s",
+		u.Calls, u.InputTokens, u.OutputTokens, u.CacheReadTokens, u.CacheWriteTokens, cost)
+}
@@ -0,0 +1,59 @@
+// Package chatstore implements chat.ChatStore and agent.UsageStore on a
+// directory of JSON files and on a SQLite database.
+package chatstore
+
+import (
+	"fmt"
+	"regexp"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+)
+
+// Store backends accepted by Open
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Default locations used when Open is given no path
+const (
+	DefaultJSONDir    = "./chats"
+	DefaultSQLitePath = "./chats.db"
+)
+
+// Chat IDs double as file names, so they are limited to a safe character set
+var chatIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// Store is a chat.ChatStore that holds resources until closed. It also keeps
+// each agent's daily usage, so budgets hold across runs.
+type Store interface {
+	chat.ChatStore
+	agent.UsageStore
+	Close() error
+}
+
+// Open opens the store for backend at path; an empty path uses the backend's default
+func Open(backend, path string) (Store, error) {
+	switch backend {
+	case BackendJSON:
+		if path == "" {
+			path = DefaultJSONDir
+		}
+		return NewJSONStore(path)
+	case BackendSQLite:
+		if path == "" {
+			path = DefaultSQLitePath
+		}
+		return NewSQLiteStore(path)
+	}
+	return nil, fmt.Errorf("unknown chat store %q (supported: %s, %s)", backend, BackendJSON, BackendSQLite)
+}
+
+// ValidateChatID rejects IDs that can't be stored
+func ValidateChatID(chatID string) error {
+	if !chatIDPattern.MatchString(chatID) {
+		return fmt.Errorf("invalid chat ID %q: use letters, digits, '.', '_' and '-', starting with a letter or digit", chatID)
+	}
+	return nil
+}
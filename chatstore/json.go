@@ -0,0 +1,201 @@
+package chatstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AnthonyL103/GOMCP/chat"
+)
+
+// usageFile holds the daily usage; chat IDs can't start with a dot, so it
+// never clashes with a chat
+const usageFile = ".usage.json"
+
+// JSONStore keeps each chat in <dir>/<chat_id>.json and daily usage in
+// <dir>/.usage.json. Every change rewrites the file through a temporary file,
+// so a crash never leaves it half written.
+type JSONStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewJSONStore uses dir, creating it if needed
+func NewJSONStore(dir string) (*JSONStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create chat directory: %w", err)
+	}
+	return &JSONStore{dir: dir}, nil
+}
+
+// AppendMessage rewrites the chat, since a JSON document can't be appended to
+func (s *JSONStore) AppendMessage(c *chat.Chat, msg chat.Message) error {
+	return s.Save(c)
+}
+
+func (s *JSONStore) Save(c *chat.Chat) error {
+	path, err := s.path(c.ChatID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode chat: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writeFile(path, data); err != nil {
+		return fmt.Errorf("failed to save chat: %w", err)
+	}
+	return nil
+}
+
+// dailyUsage is one agent's entry in .usage.json
+type dailyUsage struct {
+	Day   string     `json:"day"`
+	Usage chat.Usage `json:"usage"`
+}
+
+// AddDailyUsage keeps one entry per agent for its latest day; a new day
+// starts from zero
+func (s *JSONStore) AddDailyUsage(agentID, day string, usage chat.Usage) (chat.Usage, error) {
+	path := filepath.Join(s.dir, usageFile)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agents := map[string]dailyUsage{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return chat.Usage{}, fmt.Errorf("failed to read usage: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &agents); err != nil {
+			return chat.Usage{}, fmt.Errorf("failed to decode usage: %w", err)
+		}
+	}
+
+	entry := agents[agentID]
+	if entry.Day != day {
+		entry = dailyUsage{Day: day}
+	}
+	if usage == (chat.Usage{}) {
+		return entry.Usage, nil
+	}
+	entry.Usage.Add(usage)
+	agents[agentID] = entry
+
+	if data, err = json.MarshalIndent(agents, "", "  "); err != nil {
+		return chat.Usage{}, fmt.Errorf("failed to encode usage: %w", err)
+	}
+	if err := s.writeFile(path, data); err != nil {
+		return chat.Usage{}, fmt.Errorf("failed to save usage: %w", err)
+	}
+	return entry.Usage, nil
+}
+
+// writeFile replaces path with data through a temporary file; s.mu must be held
+func (s *JSONStore) writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(s.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *JSONStore) Load(chatID string) (*chat.Chat, error) {
+	path, err := s.path(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	data, err := os.ReadFile(path)
+	s.mu.Unlock()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", chat.ErrChatNotFound, chatID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat: %w", err)
+	}
+
+	c := &chat.Chat{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to decode chat %s: %w", chatID, err)
+	}
+	return c, nil
+}
+
+func (s *JSONStore) List() ([]chat.ChatInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chats: %w", err)
+	}
+
+	infos := []chat.ChatInfo{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") || name == usageFile {
+			continue
+		}
+
+		c, err := s.Load(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, chat.ChatInfo{
+			ChatID:       c.ChatID,
+			MessageCount: c.MessageCount(),
+			CreatedAt:    c.CreatedAt,
+			UpdatedAt:    c.UpdatedAt,
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].UpdatedAt.After(infos[j].UpdatedAt) })
+	return infos, nil
+}
+
+func (s *JSONStore) Delete(chatID string) error {
+	path, err := s.path(chatID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", chat.ErrChatNotFound, chatID)
+	}
+	return err
+}
+
+// Close is a no-op; files are closed after every write
+func (s *JSONStore) Close() error {
+	return nil
+}
+
+func (s *JSONStore) path(chatID string) (string, error) {
+	if err := ValidateChatID(chatID); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, chatID+".json"), nil
+}
@@ -0,0 +1,293 @@
+package chatstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AnthonyL103/GOMCP/chat"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS chats (
+	chat_id       TEXT PRIMARY KEY,
+	max_messages  INTEGER NOT NULL,
+	summary       TEXT NOT NULL,
+	total_usage   TEXT NOT NULL,
+	created_at    INTEGER NOT NULL,
+	updated_at    INTEGER NOT NULL,
+	active_branch TEXT NOT NULL DEFAULT '',
+	branches      TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS messages (
+	chat_id TEXT NOT NULL,
+	seq     INTEGER NOT NULL,
+	message TEXT NOT NULL,
+	PRIMARY KEY (chat_id, seq)
+);
+CREATE TABLE IF NOT EXISTS agent_usage (
+	agent_id TEXT PRIMARY KEY,
+	day      TEXT NOT NULL,
+	usage    TEXT NOT NULL
+);`
+
+// sqliteMigrations add the columns newer versions need to databases created
+// by older ones. Each must fail with "duplicate column" once applied.
+var sqliteMigrations = []string{
+	`ALTER TABLE chats ADD COLUMN active_branch TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE chats ADD COLUMN branches TEXT NOT NULL DEFAULT ''`,
+}
+
+// SQLiteStore keeps chats in a SQLite database: one row per chat and one per
+// message, so adding a message is a single insert
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens the database at path, creating it and its tables if needed
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat database: %w", err)
+	}
+	// SQLite allows one writer at a time; a single connection avoids SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create chat tables: %w", err)
+	}
+	for _, migration := range sqliteMigrations {
+		if _, err := db.Exec(migration); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, fmt.Errorf("failed to upgrade chat tables: %w", err)
+		}
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) AppendMessage(c *chat.Chat, msg chat.Message) error {
+	if err := ValidateChatID(c.ChatID); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	return s.inTx(func(tx *sql.Tx) error {
+		if err := upsertChat(tx, c); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`INSERT INTO messages (chat_id, seq, message)
+			SELECT ?, COALESCE(MAX(seq), -1) + 1, ? FROM messages WHERE chat_id = ?`,
+			c.ChatID, string(data), c.ChatID)
+		return err
+	})
+}
+
+func (s *SQLiteStore) Save(c *chat.Chat) error {
+	if err := ValidateChatID(c.ChatID); err != nil {
+		return err
+	}
+
+	messages := make([]string, len(c.Messages))
+	for i, msg := range c.Messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to encode message: %w", err)
+		}
+		messages[i] = string(data)
+	}
+
+	return s.inTx(func(tx *sql.Tx) error {
+		if err := upsertChat(tx, c); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM messages WHERE chat_id = ?`, c.ChatID); err != nil {
+			return err
+		}
+		for i, data := range messages {
+			if _, err := tx.Exec(`INSERT INTO messages (chat_id, seq, message) VALUES (?, ?, ?)`, c.ChatID, i, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) Load(chatID string) (*chat.Chat, error) {
+	c := &chat.Chat{ChatID: chatID, Messages: []chat.Message{}}
+	var usage, branches string
+	var createdAt, updatedAt int64
+
+	err := s.db.QueryRow(`SELECT max_messages, summary, total_usage, created_at, updated_at, active_branch, branches
+		FROM chats WHERE chat_id = ?`, chatID).
+		Scan(&c.MaxMessages, &c.Summary, &usage, &createdAt, &updatedAt, &c.ActiveBranch, &branches)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", chat.ErrChatNotFound, chatID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chat: %w", err)
+	}
+	if err := json.Unmarshal([]byte(usage), &c.TotalUsage); err != nil {
+		return nil, fmt.Errorf("failed to decode usage of chat %s: %w", chatID, err)
+	}
+	if branches != "" {
+		if err := json.Unmarshal([]byte(branches), &c.Branches); err != nil {
+			return nil, fmt.Errorf("failed to decode branches of chat %s: %w", chatID, err)
+		}
+	}
+	c.CreatedAt = time.Unix(0, createdAt)
+	c.UpdatedAt = time.Unix(0, updatedAt)
+
+	rows, err := s.db.Query(`SELECT message FROM messages WHERE chat_id = ? ORDER BY seq`, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load messages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to load messages: %w", err)
+		}
+		var msg chat.Message
+		if err := json.Unmarshal([]byte(data), &msg); err != nil {
+			return nil, fmt.Errorf("failed to decode message of chat %s: %w", chatID, err)
+		}
+		c.Messages = append(c.Messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load messages: %w", err)
+	}
+	return c, nil
+}
+
+func (s *SQLiteStore) List() ([]chat.ChatInfo, error) {
+	rows, err := s.db.Query(`SELECT c.chat_id, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM messages m WHERE m.chat_id = c.chat_id)
+		FROM chats c ORDER BY c.updated_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chats: %w", err)
+	}
+	defer rows.Close()
+
+	infos := []chat.ChatInfo{}
+	for rows.Next() {
+		var info chat.ChatInfo
+		var createdAt, updatedAt int64
+		if err := rows.Scan(&info.ChatID, &createdAt, &updatedAt, &info.MessageCount); err != nil {
+			return nil, fmt.Errorf("failed to list chats: %w", err)
+		}
+		info.CreatedAt = time.Unix(0, createdAt)
+		info.UpdatedAt = time.Unix(0, updatedAt)
+		infos = append(infos, info)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list chats: %w", err)
+	}
+	return infos, nil
+}
+
+func (s *SQLiteStore) Delete(chatID string) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM chats WHERE chat_id = ?`, chatID)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("%w: %s", chat.ErrChatNotFound, chatID)
+		}
+		_, err = tx.Exec(`DELETE FROM messages WHERE chat_id = ?`, chatID)
+		return err
+	})
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// AddDailyUsage keeps one row per agent for its latest day; a new day starts
+// from zero
+func (s *SQLiteStore) AddDailyUsage(agentID, day string, usage chat.Usage) (chat.Usage, error) {
+	var total chat.Usage
+	err := s.inTx(func(tx *sql.Tx) error {
+		var storedDay, stored string
+		err := tx.QueryRow(`SELECT day, usage FROM agent_usage WHERE agent_id = ?`, agentID).Scan(&storedDay, &stored)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil && storedDay == day {
+			if err := json.Unmarshal([]byte(stored), &total); err != nil {
+				return fmt.Errorf("failed to decode usage for agent %s: %w", agentID, err)
+			}
+		}
+		if usage == (chat.Usage{}) {
+			return nil
+		}
+		total.Add(usage)
+
+		data, err := json.Marshal(total)
+		if err != nil {
+			return fmt.Errorf("failed to encode usage: %w", err)
+		}
+		_, err = tx.Exec(`INSERT INTO agent_usage (agent_id, day, usage) VALUES (?, ?, ?)
+			ON CONFLICT (agent_id) DO UPDATE SET day = excluded.day, usage = excluded.usage`,
+			agentID, day, string(data))
+		return err
+	})
+	if err != nil {
+		return chat.Usage{}, err
+	}
+	return total, nil
+}
+
+// inTx runs fn in a transaction, committing only if it succeeds
+func (s *SQLiteStore) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// upsertChat writes everything about c except the active branch's messages;
+// other branches are stored whole as JSON
+func upsertChat(tx *sql.Tx, c *chat.Chat) error {
+	usage, err := json.Marshal(c.TotalUsage)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage: %w", err)
+	}
+
+	branches := ""
+	if len(c.Branches) > 0 {
+		data, err := json.Marshal(c.Branches)
+		if err != nil {
+			return fmt.Errorf("failed to encode branches: %w", err)
+		}
+		branches = string(data)
+	}
+
+	_, err = tx.Exec(`INSERT INTO chats (chat_id, max_messages, summary, total_usage, created_at, updated_at, active_branch, branches)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (chat_id) DO UPDATE SET
+			max_messages = excluded.max_messages,
+			summary = excluded.summary,
+			total_usage = excluded.total_usage,
+			updated_at = excluded.updated_at,
+			active_branch = excluded.active_branch,
+			branches = excluded.branches`,
+		c.ChatID, c.MaxMessages, c.Summary, string(usage), c.CreatedAt.UnixNano(), c.UpdatedAt.UnixNano(), c.ActiveBranch, branches)
+	return err
+}
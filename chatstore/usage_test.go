@@ -0,0 +1,53 @@
+package chatstore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/AnthonyL103/GOMCP/chat"
+)
+
+func TestDailyUsage(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store")
+			store, err := Open(backend, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			call := chat.Usage{Model: "gpt-4o", Calls: 1, InputTokens: 10, CostUSD: 0.5}
+			store.AddDailyUsage("helper", "2026-10-16", call)
+			store.AddDailyUsage("helper", "2026-10-17", call)
+			if _, err := store.AddDailyUsage("helper", "2026-10-17", call); err != nil {
+				t.Fatal(err)
+			}
+			store.AddDailyUsage("other", "2026-10-17", call)
+			store.Close()
+
+			// A new run reads what the last one spent
+			store, err = Open(backend, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			total, err := store.AddDailyUsage("helper", "2026-10-17", chat.Usage{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total.Calls != 2 || total.CostUSD != 1.0 || total.Model != "gpt-4o" {
+				t.Fatalf("today's usage = %+v, want 2 calls costing $1.00", total)
+			}
+
+			if total, _ := store.AddDailyUsage("helper", "2026-10-18", chat.Usage{}); total.Calls != 0 {
+				t.Fatalf("a new day starts at %+v, want zero", total)
+			}
+
+			infos, err := store.List()
+			if err != nil || len(infos) != 0 {
+				t.Fatalf("List() = %v, %v; usage must not show up as a chat", infos, err)
+			}
+		})
+	}
+}
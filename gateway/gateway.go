@@ -0,0 +1,360 @@
+// Package gateway exposes a configured agent as an MCP server so other MCP
+// clients (IDEs, other agents) can call every registry tool plus a chat tool
+// that runs the agent's own LLM loop.
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
+	"github.com/AnthonyL103/GOMCP/protocol/mcpprotocol"
+	"github.com/AnthonyL103/GOMCP/transport"
+)
+
+const (
+	ChatToolName       = "chat"
+	defaultChatSession = "default"
+	serverVersion      = "0.1.0"
+)
+
+// Server answers MCP JSON-RPC requests on behalf of an agent. Each client
+// connection (a stdio stream or an HTTP MCP session) has its own chat
+// sessions, so clients never see each other's conversations.
+type Server struct {
+	agent    *agent.Agent
+	provider transport.Provider
+
+	// sessions holds each open connection's chats, keyed by connection ID
+	// and then by the chat tool's session_id
+	mu       sync.Mutex
+	sessions map[string]map[string]*chatSession
+
+	// inflight holds the cancel func of every running request, keyed by
+	// inflightKey, so notifications/cancelled can stop it
+	inflightMu sync.Mutex
+	inflight   map[string]context.CancelFunc
+
+	// AllowOrigins lists the browser origins allowed to call ServeHTTP.
+	// Requests with any other Origin header are rejected, which guards
+	// against DNS rebinding; clients that send no Origin are not browsers
+	// and are always allowed.
+	AllowOrigins []string
+}
+
+type chatSession struct {
+	mu   sync.Mutex
+	chat *chat.Chat
+}
+
+func NewServer(ag *agent.Agent, provider transport.Provider) *Server {
+	return &Server{
+		agent:    ag,
+		provider: provider,
+		sessions: make(map[string]map[string]*chatSession),
+		inflight: make(map[string]context.CancelFunc),
+	}
+}
+
+// OpenConnection registers a client connection and returns its ID, which
+// scopes the client's chat sessions. Transports call it once per client.
+func (s *Server) OpenConnection() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate connection id: %w", err)
+	}
+	connID := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	s.sessions[connID] = make(map[string]*chatSession)
+	s.mu.Unlock()
+	return connID, nil
+}
+
+// CloseConnection drops a connection and its chat sessions
+func (s *Server) CloseConnection(connID string) {
+	s.mu.Lock()
+	delete(s.sessions, connID)
+	s.mu.Unlock()
+}
+
+// hasConnection reports whether connID is open
+func (s *Server) hasConnection(connID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.sessions[connID]
+	return exists
+}
+
+// Handle processes one JSON-RPC message from connection connID. Notifications
+// return nil. The request runs under ctx and is also cancelled by a matching
+// notifications/cancelled.
+func (s *Server) Handle(ctx context.Context, connID string, req *mcpprotocol.Request) *mcpprotocol.Response {
+	if req.IsNotification() {
+		if req.Method == mcpprotocol.MethodNotificationCancelled {
+			s.cancelInflight(connID, req.Params)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	key := inflightKey(connID, req.ID)
+	s.inflightMu.Lock()
+	s.inflight[key] = cancel
+	s.inflightMu.Unlock()
+	defer func() {
+		s.inflightMu.Lock()
+		delete(s.inflight, key)
+		s.inflightMu.Unlock()
+		cancel()
+	}()
+
+	result, rpcErr := s.dispatch(ctx, connID, req)
+	response := &mcpprotocol.Response{JSONRPC: mcpprotocol.JSONRPCVersion, ID: req.ID}
+	if rpcErr != nil {
+		response.Error = rpcErr
+		return response
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		response.Error = &mcpprotocol.RPCError{Code: mcpprotocol.CodeInternalError, Message: err.Error()}
+		return response
+	}
+	response.Result = data
+	return response
+}
+
+// HandleMessage decodes a raw message (single or batch) from connection connID
+// and returns the encoded reply, or nil when nothing needs to be sent back.
+func (s *Server) HandleMessage(ctx context.Context, connID string, data []byte) []byte {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var batch []mcpprotocol.Request
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return encodeResponse(parseErrorResponse(err))
+		}
+
+		responses := []*mcpprotocol.Response{}
+		for i := range batch {
+			if resp := s.Handle(ctx, connID, &batch[i]); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		out, _ := json.Marshal(responses)
+		return out
+	}
+
+	var req mcpprotocol.Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return encodeResponse(parseErrorResponse(err))
+	}
+
+	resp := s.Handle(ctx, connID, &req)
+	if resp == nil {
+		return nil
+	}
+	return encodeResponse(resp)
+}
+
+// inflightKey scopes a JSON-RPC ID to its connection, since every client
+// numbers its requests independently
+func inflightKey(connID string, id json.RawMessage) string {
+	return connID + " " + string(id)
+}
+
+// cancelInflight stops the request named by a notifications/cancelled message.
+// Only requests from the same connection can be cancelled.
+func (s *Server) cancelInflight(connID string, raw json.RawMessage) {
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil || len(params.RequestID) == 0 {
+		return
+	}
+
+	s.inflightMu.Lock()
+	cancel, exists := s.inflight[inflightKey(connID, params.RequestID)]
+	s.inflightMu.Unlock()
+	if exists {
+		cancel()
+	}
+}
+
+func (s *Server) dispatch(ctx context.Context, connID string, req *mcpprotocol.Request) (interface{}, *mcpprotocol.RPCError) {
+	switch req.Method {
+	case mcpprotocol.MethodInitialize:
+		return s.initialize(req.Params)
+	case mcpprotocol.MethodPing:
+		return map[string]interface{}{}, nil
+	case mcpprotocol.MethodToolsList:
+		return mcpprotocol.ListToolsResult{Tools: s.listTools()}, nil
+	case mcpprotocol.MethodToolsCall:
+		var params mcpprotocol.CallToolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &mcpprotocol.RPCError{Code: mcpprotocol.CodeInvalidParams, Message: fmt.Sprintf("invalid tools/call params: %v", err)}
+		}
+		return s.callTool(ctx, connID, params)
+	default:
+		return nil, &mcpprotocol.RPCError{Code: mcpprotocol.CodeMethodNotFound, Message: fmt.Sprintf("method %s not found", req.Method)}
+	}
+}
+
+func (s *Server) initialize(raw json.RawMessage) (interface{}, *mcpprotocol.RPCError) {
+	var params mcpprotocol.InitializeParams
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, &mcpprotocol.RPCError{Code: mcpprotocol.CodeInvalidParams, Message: fmt.Sprintf("invalid initialize params: %v", err)}
+		}
+	}
+
+	// Echo the client's revision when we support it, otherwise offer ours
+	version := mcpprotocol.ProtocolVersion
+	for _, supported := range mcpprotocol.SupportedProtocolVersions {
+		if params.ProtocolVersion == supported {
+			version = supported
+		}
+	}
+
+	return mcpprotocol.InitializeResult{
+		ProtocolVersion: version,
+		Capabilities: map[string]interface{}{
+			"tools": map[string]interface{}{"listChanged": false},
+		},
+		ServerInfo:   mcpprotocol.Implementation{Name: s.agent.AgentID, Version: serverVersion},
+		Instructions: s.agent.Description,
+	}, nil
+}
+
+// listTools publishes every registry tool plus the chat tool, sorted by name
+func (s *Server) listTools() []mcpprotocol.ToolDescriptor {
+	tools := []mcpprotocol.ToolDescriptor{}
+
+	for toolID, info := range llmprotocol.ExtractTools(s.agent) {
+		schema := info.Schema
+		if schema == nil {
+			schema = map[string]interface{}{}
+		}
+		schema["type"] = "object"
+		tools = append(tools, mcpprotocol.ToolDescriptor{
+			Name:        toolID,
+			Description: info.Description,
+			InputSchema: schema,
+		})
+	}
+
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+
+	return append(tools, mcpprotocol.ToolDescriptor{
+		Name:        ChatToolName,
+		Description: fmt.Sprintf("Send a message to %s and get its reply. %s", s.agent.AgentID, s.agent.Description),
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"message":    map[string]interface{}{"type": "string", "description": "User message for the agent"},
+				"session_id": map[string]interface{}{"type": "string", "description": "Conversation to continue; each client has its own (defaults to the client's default conversation)"},
+			},
+			"required": []string{"message"},
+		},
+	})
+}
+
+func (s *Server) callTool(ctx context.Context, connID string, params mcpprotocol.CallToolParams) (interface{}, *mcpprotocol.RPCError) {
+	if params.Name == ChatToolName {
+		return s.callChat(ctx, connID, params.Arguments), nil
+	}
+
+	toolInfo, exists := llmprotocol.ExtractTools(s.agent)[params.Name]
+	if !exists {
+		return nil, &mcpprotocol.RPCError{Code: mcpprotocol.CodeInvalidParams, Message: fmt.Sprintf("tool %s not found", params.Name)}
+	}
+
+	output, isError := llmprotocol.ExecuteTool(ctx, s.agent, &chat.ToolCall{
+		ServerID:   toolInfo.ServerID,
+		ToolID:     params.Name,
+		Handler:    toolInfo.Handler,
+		Parameters: params.Arguments,
+	})
+
+	return textResult(output, isError), nil
+}
+
+func (s *Server) callChat(ctx context.Context, connID string, arguments map[string]interface{}) *mcpprotocol.CallToolResult {
+	message, _ := arguments["message"].(string)
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return textResult("chat requires a non-empty 'message' argument", true)
+	}
+
+	sessionID, _ := arguments["session_id"].(string)
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		sessionID = defaultChatSession
+	}
+
+	session, err := s.getSession(connID, sessionID)
+	if err != nil {
+		return textResult(err.Error(), true)
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if err := s.provider.SendRequest(ctx, session.chat, s.agent, message); err != nil {
+		return textResult(fmt.Sprintf("Agent request failed: %v", err), true)
+	}
+
+	messages := session.chat.GetMessages()
+	if len(messages) == 0 || messages[len(messages)-1].Role != chat.RoleAssistant {
+		return textResult("", false)
+	}
+	return textResult(messages[len(messages)-1].Text(), false)
+}
+
+// getSession returns the connection's chat session, starting it if needed
+func (s *Server) getSession(connID, sessionID string) (*chatSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, open := s.sessions[connID]
+	if !open {
+		return nil, fmt.Errorf("connection closed")
+	}
+
+	session, exists := sessions[sessionID]
+	if !exists {
+		session = &chatSession{chat: s.agent.NewChat(sessionID)}
+		sessions[sessionID] = session
+	}
+	return session, nil
+}
+
+func textResult(text string, isError bool) *mcpprotocol.CallToolResult {
+	return &mcpprotocol.CallToolResult{
+		Content: []mcpprotocol.ContentItem{{Type: "text", Text: text}},
+		IsError: isError,
+	}
+}
+
+func parseErrorResponse(err error) *mcpprotocol.Response {
+	return &mcpprotocol.Response{
+		JSONRPC: mcpprotocol.JSONRPCVersion,
+		ID:      json.RawMessage("null"),
+		Error:   &mcpprotocol.RPCError{Code: mcpprotocol.CodeParseError, Message: err.Error()},
+	}
+}
+
+func encodeResponse(resp *mcpprotocol.Response) []byte {
+	out, _ := json.Marshal(resp)
+	return out
+}
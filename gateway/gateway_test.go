@@ -0,0 +1,208 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/protocol/mcpprotocol"
+	"github.com/AnthonyL103/GOMCP/registry"
+	"github.com/AnthonyL103/GOMCP/transport"
+)
+
+// countingProvider replies with how many user messages the chat holds, so
+// tests can tell whether two calls shared a conversation
+type countingProvider struct{}
+
+func (countingProvider) SendRequest(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string) error {
+	c.AddUserMessage(userMessage)
+	turns := 0
+	for _, msg := range c.GetMessages() {
+		if msg.Role == chat.RoleUser {
+			turns++
+		}
+	}
+	c.AddAssistantMessage(chat.TextBlock(fmt.Sprintf("turn %d", turns)))
+	return nil
+}
+
+func (p countingProvider) SendRequestStream(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string, onEvent transport.StreamHandler) error {
+	return p.SendRequest(ctx, c, ag, userMessage)
+}
+
+func (countingProvider) GetProviderName() string { return "test" }
+
+func newTestServer() *Server {
+	ag := agent.NewAgent("tester", "Test agent", registry.NewRegistry(), &agent.LLMConfig{
+		APIKey:      "test",
+		Model:       "gpt-4o",
+		Temperature: 0.5,
+		MaxTokens:   100,
+	}, false, false, false)
+	return NewServer(ag, countingProvider{})
+}
+
+// mcpPost sends one JSON-RPC request and returns the response and its result
+func mcpPost(t *testing.T, url, connID, method string, params interface{}) (*http.Response, json.RawMessage) {
+	t.Helper()
+	req, err := mcpprotocol.NewRequest(1, method, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(req)
+
+	httpReq, _ := http.NewRequest("POST", url, bytes.NewReader(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	if connID != "" {
+		httpReq.Header.Set(mcpprotocol.SessionHeader, connID)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var reply mcpprotocol.Response
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp, reply.Result
+}
+
+func initialize(t *testing.T, url string) string {
+	t.Helper()
+	resp, _ := mcpPost(t, url, "", mcpprotocol.MethodInitialize, mcpprotocol.InitializeParams{ProtocolVersion: mcpprotocol.ProtocolVersion})
+	connID := resp.Header.Get(mcpprotocol.SessionHeader)
+	if resp.StatusCode != http.StatusOK || connID == "" {
+		t.Fatalf("initialize: status %d, session %q", resp.StatusCode, connID)
+	}
+	return connID
+}
+
+func chatReply(t *testing.T, url, connID string, arguments map[string]interface{}) string {
+	t.Helper()
+	resp, result := mcpPost(t, url, connID, mcpprotocol.MethodToolsCall, mcpprotocol.CallToolParams{Name: ChatToolName, Arguments: arguments})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("chat: status %d", resp.StatusCode)
+	}
+	var call mcpprotocol.CallToolResult
+	if err := json.Unmarshal(result, &call); err != nil || len(call.Content) == 0 {
+		t.Fatalf("chat result %s: %v", result, err)
+	}
+	return call.Content[0].Text
+}
+
+func TestHTTPClientsGetSeparateChats(t *testing.T) {
+	srv := httptest.NewServer(newTestServer())
+	defer srv.Close()
+
+	first := initialize(t, srv.URL)
+	second := initialize(t, srv.URL)
+	if first == second {
+		t.Fatal("two clients got the same MCP session")
+	}
+
+	message := map[string]interface{}{"message": "hi"}
+	if got := chatReply(t, srv.URL, first, message); got != "turn 1" {
+		t.Fatalf("first client: %q, want turn 1", got)
+	}
+	if got := chatReply(t, srv.URL, first, message); got != "turn 2" {
+		t.Fatalf("first client again: %q, want turn 2", got)
+	}
+	// Neither the default chat nor a named one is shared with another client
+	if got := chatReply(t, srv.URL, second, message); got != "turn 1" {
+		t.Fatalf("second client: %q, want turn 1", got)
+	}
+	named := map[string]interface{}{"message": "hi", "session_id": "work"}
+	chatReply(t, srv.URL, first, named)
+	if got := chatReply(t, srv.URL, second, named); got != "turn 1" {
+		t.Fatalf("second client's named session: %q, want turn 1", got)
+	}
+}
+
+func TestHTTPRequiresSession(t *testing.T) {
+	srv := httptest.NewServer(newTestServer())
+	defer srv.Close()
+
+	if resp, _ := mcpPost(t, srv.URL, "", mcpprotocol.MethodToolsList, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("request without session: status %d, want 400", resp.StatusCode)
+	}
+	if resp, _ := mcpPost(t, srv.URL, "unknown", mcpprotocol.MethodToolsList, nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("request with unknown session: status %d, want 404", resp.StatusCode)
+	}
+
+	connID := initialize(t, srv.URL)
+	if resp, _ := mcpPost(t, srv.URL, connID, mcpprotocol.MethodToolsList, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("tools/list: status %d, want 200", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest("DELETE", srv.URL, nil)
+	req.Header.Set(mcpprotocol.SessionHeader, connID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE: status %d, want 204", resp.StatusCode)
+	}
+	if resp, _ := mcpPost(t, srv.URL, connID, mcpprotocol.MethodToolsList, nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("request after DELETE: status %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHTTPRejectsUnknownOrigin(t *testing.T) {
+	gw := newTestServer()
+	gw.AllowOrigins = []string{"https://app.example.com"}
+	srv := httptest.NewServer(gw)
+	defer srv.Close()
+
+	for origin, want := range map[string]int{
+		"":                        http.StatusOK,
+		"https://app.example.com": http.StatusOK,
+		"http://evil.example.com": http.StatusForbidden,
+	} {
+		body := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}}`
+		req, _ := http.NewRequest("POST", srv.URL, bytes.NewReader([]byte(body)))
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("Origin %q: status %d, want %d", origin, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestCancelOnlyReachesSameConnection(t *testing.T) {
+	gw := newTestServer()
+	first, _ := gw.OpenConnection()
+	second, _ := gw.OpenConnection()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gw.inflight[inflightKey(first, json.RawMessage(`7`))] = cancel
+
+	notification := []byte(`{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": {"requestId": 7}}`)
+	gw.HandleMessage(context.Background(), second, notification)
+	if ctx.Err() != nil {
+		t.Fatal("another connection cancelled the request")
+	}
+
+	gw.HandleMessage(context.Background(), first, notification)
+	if ctx.Err() == nil {
+		t.Fatal("the request's own connection could not cancel it")
+	}
+}
@@ -0,0 +1,142 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/AnthonyL103/GOMCP/protocol/mcpprotocol"
+)
+
+const maxMessageBytes = 10 * 1024 * 1024
+
+// ServeStdio reads newline-delimited JSON-RPC messages from in and writes
+// replies to out until in is closed. Requests are handled concurrently so a
+// long chat call does not block pings or tool listings. Cancelling ctx
+// cancels every request still running. The stream is one client connection.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	connID, err := s.OpenConnection()
+	if err != nil {
+		return err
+	}
+	defer s.CloseConnection(connID)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageBytes)
+
+	var writeMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for scanner.Scan() {
+		line := append([]byte{}, scanner.Bytes()...)
+		if len(line) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reply := s.HandleMessage(ctx, connID, line)
+			if reply == nil {
+				return
+			}
+
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			if _, err := out.Write(append(reply, '\n')); err != nil {
+				log.Printf("Failed to write MCP reply: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return scanner.Err()
+}
+
+// ServeHTTP implements the MCP streamable HTTP transport for a single endpoint.
+// Each POST carries one message (or batch) and is answered with a JSON body;
+// the server never opens a standalone event stream, so GET is rejected.
+// initialize starts an MCP session whose ID the client sends back in the
+// Mcp-Session-Id header; DELETE ends it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.allowOrigin(r.Header.Get("Origin")) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	connID := r.Header.Get(mcpprotocol.SessionHeader)
+
+	switch r.Method {
+	case "POST":
+	case "DELETE":
+		if !s.hasConnection(connID) {
+			http.Error(w, "Unknown MCP session", http.StatusNotFound)
+			return
+		}
+		s.CloseConnection(connID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case connID == "" && isInitialize(body):
+		if connID, err = s.OpenConnection(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(mcpprotocol.SessionHeader, connID)
+	case connID == "":
+		http.Error(w, "Missing "+mcpprotocol.SessionHeader+" header; send initialize first", http.StatusBadRequest)
+		return
+	case !s.hasConnection(connID):
+		// The client must start a new session with initialize
+		http.Error(w, "Unknown MCP session", http.StatusNotFound)
+		return
+	}
+
+	// The request context ends when the client disconnects
+	reply := s.HandleMessage(r.Context(), connID, body)
+	if reply == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(reply)
+}
+
+// isInitialize reports whether body is a single initialize request
+func isInitialize(body []byte) bool {
+	var msg struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal(body, &msg) == nil && msg.Method == mcpprotocol.MethodInitialize
+}
+
+// allowOrigin reports whether a request with this Origin header may be served
+func (s *Server) allowOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range s.AllowOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	return false
+}
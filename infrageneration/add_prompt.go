@@ -141,37 +141,6 @@ func SharedAWSToolDefinitions() []ToolDefinition {
 	}
 }
 
-// OpenAIToolSpecs converts the AWS infra tool definitions into OpenAI function specs.
-func OpenAIToolSpecs() []map[string]interface{} {
-	defs := SharedAWSToolDefinitions()
-	tools := make([]map[string]interface{}, 0, len(defs))
-	for _, def := range defs {
-		tools = append(tools, map[string]interface{}{
-			"type": "function",
-			"function": map[string]interface{}{
-				"name":        def.Name,
-				"description": def.Description,
-				"parameters":  def.InputSchema,
-			},
-		})
-	}
-	return tools
-}
-
-// AnthropicToolSpecs converts the AWS infra tool definitions into Anthropic tool specs.
-func AnthropicToolSpecs() []map[string]interface{} {
-	defs := SharedAWSToolDefinitions()
-	tools := make([]map[string]interface{}, 0, len(defs))
-	for _, def := range defs {
-		tools = append(tools, map[string]interface{}{
-			"name":         def.Name,
-			"description":  def.Description,
-			"input_schema": def.InputSchema,
-		})
-	}
-	return tools
-}
-
 // IsInfraGenerationTool reports whether the name belongs to the AWS infra flow.
 func IsInfraGenerationTool(name string) bool {
 	for _, def := range SharedAWSToolDefinitions() {
@@ -1,6 +1,7 @@
 package infrageneration
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -8,7 +9,8 @@ import (
 	agent "github.com/AnthonyL103/GOMCP/Agent"
 )
 
-func CollectAWSRequirementsTool(ag *agent.Agent, params map[string]interface{}) (string, bool) {
+func CollectAWSRequirementsTool(ctx context.Context, ag *agent.Agent, params map[string]interface{}) (string, bool) {
+	_ = ctx
 	_ = ag
 	return formatInfraStagePreview(
 		ToolCollectAWSRequirements,
@@ -18,7 +20,8 @@ func CollectAWSRequirementsTool(ag *agent.Agent, params map[string]interface{})
 	), false
 }
 
-func CollectAWSCredentialsTool(ag *agent.Agent, params map[string]interface{}) (string, bool) {
+func CollectAWSCredentialsTool(ctx context.Context, ag *agent.Agent, params map[string]interface{}) (string, bool) {
+	_ = ctx
 	_ = ag
 	return formatInfraStagePreview(
 		ToolCollectAWSCredentials,
@@ -28,7 +31,8 @@ func CollectAWSCredentialsTool(ag *agent.Agent, params map[string]interface{}) (
 	), false
 }
 
-func GenerateAWSTerraformTool(ag *agent.Agent, params map[string]interface{}) (string, bool) {
+func GenerateAWSTerraformTool(ctx context.Context, ag *agent.Agent, params map[string]interface{}) (string, bool) {
+	_ = ctx
 	_ = ag
 	return formatInfraStagePreview(
 		ToolGenerateAWSTerraform,
@@ -38,7 +42,8 @@ func GenerateAWSTerraformTool(ag *agent.Agent, params map[string]interface{}) (s
 	), false
 }
 
-func ValidateAWSTerraformTool(ag *agent.Agent, params map[string]interface{}) (string, bool) {
+func ValidateAWSTerraformTool(ctx context.Context, ag *agent.Agent, params map[string]interface{}) (string, bool) {
+	_ = ctx
 	_ = ag
 	return formatInfraStagePreview(
 		ToolValidateAWSTerraform,
@@ -48,7 +53,8 @@ func ValidateAWSTerraformTool(ag *agent.Agent, params map[string]interface{}) (s
 	), false
 }
 
-func DeployAWSTerraformTool(ag *agent.Agent, params map[string]interface{}) (string, bool) {
+func DeployAWSTerraformTool(ctx context.Context, ag *agent.Agent, params map[string]interface{}) (string, bool) {
+	_ = ctx
 	_ = ag
 	_ = params
 	return "true", false
@@ -1,6 +1,26 @@
 package main
 
+import (
+	"os"
+)
 
 func main() {
-	runagent()
-}
\ No newline at end of file
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "mcp":
+			runmcp(os.Args[2:])
+			return
+		case "serve":
+			runserve(os.Args[2:])
+			return
+		case "export":
+			runexport(os.Args[2:])
+			return
+		case "import":
+			runimport(os.Args[2:])
+			return
+		}
+	}
+
+	runagent(os.Args[1:])
+}
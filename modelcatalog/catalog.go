@@ -0,0 +1,162 @@
+package modelcatalog
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed models.yaml
+var defaultCatalog []byte
+
+// DefaultOverridePath is where an optional catalog override is looked for
+const DefaultOverridePath = "./modelcatalog.yaml"
+
+// Model describes one model the agent can run on
+type Model struct {
+	ID              string  `yaml:"id"`
+	Provider        string  `yaml:"provider"` // "anthropic", "openai", "gemini"
+	ContextWindow   int     `yaml:"context_window"`
+	MaxOutputTokens int     `yaml:"max_output_tokens"`
+	ToolCalling     bool    `yaml:"tool_calling"`
+	Pricing         Pricing `yaml:"pricing"`
+}
+
+// UnmarshalYAML defaults tool_calling to true, so override entries only need
+// to set it for models that lack function calling
+func (m *Model) UnmarshalYAML(value *yaml.Node) error {
+	type plain Model
+	decoded := plain{ToolCalling: true}
+	if err := value.Decode(&decoded); err != nil {
+		return err
+	}
+	*m = Model(decoded)
+	return nil
+}
+
+// Pricing is in USD per million tokens. Zero cache prices fall back to the input price.
+type Pricing struct {
+	InputPerMTok      float64 `yaml:"input_per_mtok"`
+	OutputPerMTok     float64 `yaml:"output_per_mtok"`
+	CacheReadPerMTok  float64 `yaml:"cache_read_per_mtok"`
+	CacheWritePerMTok float64 `yaml:"cache_write_per_mtok"`
+}
+
+type catalogFile struct {
+	Models []Model `yaml:"models"`
+}
+
+// Catalog holds the known models by ID
+type Catalog struct {
+	mu     sync.RWMutex
+	models map[string]Model
+}
+
+var catalog = mustLoadDefault()
+
+func mustLoadDefault() *Catalog {
+	c := &Catalog{models: map[string]Model{}}
+	if err := c.merge(defaultCatalog); err != nil {
+		panic(fmt.Sprintf("invalid embedded model catalog: %v", err))
+	}
+	return c
+}
+
+// merge adds the models in a catalog file, replacing entries with the same ID
+func (c *Catalog) merge(data []byte) error {
+	var file catalogFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse model catalog: %w", err)
+	}
+
+	for i, model := range file.Models {
+		if model.ID == "" {
+			return fmt.Errorf("model %d has no id", i)
+		}
+		if model.Provider == "" {
+			return fmt.Errorf("model %s has no provider", model.ID)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, model := range file.Models {
+		c.models[model.ID] = model
+	}
+	return nil
+}
+
+// LoadOverride merges a YAML catalog over the embedded one. Entries replace
+// the default model with the same id; a missing file is not an error.
+func LoadOverride(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read model catalog %s: %w", path, err)
+	}
+
+	if err := catalog.merge(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Lookup returns the catalog entry for a model ID
+func Lookup(id string) (Model, bool) {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+	model, ok := catalog.models[id]
+	return model, ok
+}
+
+// IDs lists every known model ID, sorted
+func IDs() []string {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+
+	ids := make([]string, 0, len(catalog.models))
+	for id := range catalog.models {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SupportsTools reports whether a model can be offered tools. Models missing
+// from the catalog (e.g. on self-hosted endpoints) are assumed to support them.
+func SupportsTools(id string) bool {
+	model, ok := Lookup(id)
+	return !ok || model.ToolCalling
+}
+
+// Cost prices one call's tokens in USD. It reports false when the model has
+// no price in the catalog.
+func Cost(id string, inputTokens, outputTokens, cacheReadTokens, cacheWriteTokens int) (float64, bool) {
+	model, ok := Lookup(id)
+	pricing := model.Pricing
+	if !ok || (pricing.InputPerMTok == 0 && pricing.OutputPerMTok == 0) {
+		return 0, false
+	}
+
+	cacheRead := pricing.CacheReadPerMTok
+	if cacheRead == 0 {
+		cacheRead = pricing.InputPerMTok
+	}
+	cacheWrite := pricing.CacheWritePerMTok
+	if cacheWrite == 0 {
+		cacheWrite = pricing.InputPerMTok
+	}
+
+	cost := float64(inputTokens)*pricing.InputPerMTok +
+		float64(outputTokens)*pricing.OutputPerMTok +
+		float64(cacheReadTokens)*cacheRead +
+		float64(cacheWriteTokens)*cacheWrite
+	return cost / 1e6, true
+}
@@ -2,6 +2,7 @@ package llmprotocol
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,11 +11,14 @@ import (
 	agent "github.com/AnthonyL103/GOMCP/Agent"
 	"github.com/AnthonyL103/GOMCP/chat"
 	"github.com/AnthonyL103/GOMCP/infrageneration"
+	"github.com/AnthonyL103/GOMCP/protocol/mcpprotocol"
 	"github.com/AnthonyL103/GOMCP/server"
 	"github.com/AnthonyL103/GOMCP/servergeneration"
 )
 
-func ExecuteTool(ag *agent.Agent, tc *chat.ToolCall) (string, bool) {
+// ExecuteTool runs a tool call. Cancelling ctx aborts the tool's network
+// call or child process and returns an error result.
+func ExecuteTool(ctx context.Context, ag *agent.Agent, tc *chat.ToolCall) (string, bool) {
 	if ag == nil {
 		panic("Agent does not exist")
 	}
@@ -22,66 +26,88 @@ func ExecuteTool(ag *agent.Agent, tc *chat.ToolCall) (string, bool) {
 		panic("Tool Call does not exist")
 	}
 
+	if err := ctx.Err(); err != nil {
+		return fmt.Sprintf("Tool '%s' cancelled: %v", tc.ToolID, err), true
+	}
+
 	if tc.ToolID == servergeneration.ToolGenerateServerCode {
-		return servergeneration.GenerateServerCodeTool(ag, tc.Parameters)
+		return servergeneration.GenerateServerCodeTool(ctx, ag, tc.Parameters)
 	}
 
 	if tc.ToolID == servergeneration.ToolDeployAndTestTools {
-		return servergeneration.DeployAndTestToolsTool(ag, tc.Parameters)
+		return servergeneration.DeployAndTestToolsTool(ctx, ag, tc.Parameters)
 	}
 
 	if tc.ToolID == servergeneration.ToolDeployAndRegister {
-		return servergeneration.DeployAndRegisterServerTool(ag, tc.Parameters)
+		return servergeneration.DeployAndRegisterServerTool(ctx, ag, tc.Parameters)
 	}
 
 	if tc.ToolID == servergeneration.ToolCleanupServerGeneration {
-		return servergeneration.CleanupServerGenerationTool(ag, tc.Parameters)
+		return servergeneration.CleanupServerGenerationTool(ctx, ag, tc.Parameters)
 	}
 
 	if tc.ToolID == servergeneration.ToolDeleteServer {
-		return servergeneration.DeleteServerTool(ag, tc.Parameters)
+		return servergeneration.DeleteServerTool(ctx, ag, tc.Parameters)
 	}
 
 	if tc.ToolID == infrageneration.ToolCollectAWSRequirements {
-		return infrageneration.CollectAWSRequirementsTool(ag, tc.Parameters)
+		return infrageneration.CollectAWSRequirementsTool(ctx, ag, tc.Parameters)
 	}
 
 	if tc.ToolID == infrageneration.ToolCollectAWSCredentials {
-		return infrageneration.CollectAWSCredentialsTool(ag, tc.Parameters)
+		return infrageneration.CollectAWSCredentialsTool(ctx, ag, tc.Parameters)
 	}
 
 	if tc.ToolID == infrageneration.ToolGenerateAWSTerraform {
-		return infrageneration.GenerateAWSTerraformTool(ag, tc.Parameters)
+		return infrageneration.GenerateAWSTerraformTool(ctx, ag, tc.Parameters)
 	}
 
 	if tc.ToolID == infrageneration.ToolValidateAWSTerraform {
-		return infrageneration.ValidateAWSTerraformTool(ag, tc.Parameters)
+		return infrageneration.ValidateAWSTerraformTool(ctx, ag, tc.Parameters)
 	}
 
 	if tc.ToolID == infrageneration.ToolDeployAWSTerraform {
-		return infrageneration.DeployAWSTerraformTool(ag, tc.Parameters)
+		return infrageneration.DeployAWSTerraformTool(ctx, ag, tc.Parameters)
 	}
 
 	// Get the server
-	srv, exists := ag.Registry.Servers[tc.ServerID]
-	if !exists {
+	srv, err := ag.Registry.GetServer(tc.ServerID)
+	if err != nil {
 		return fmt.Sprintf("Server '%s' not found", tc.ServerID), true
 	}
 
 	// Get the tool
-	_, exists = srv.Tools[tc.ToolID]
-	if !exists {
+	if _, exists := srv.LookupTool(tc.ToolID); !exists {
 		return fmt.Sprintf("Tool '%s' not found in server '%s'", tc.ToolID, tc.ServerID), true
 	}
 
 	runtimeConfig := srv.RuntimeConfig
 
+	if runtimeConfig.IsMCP() {
+		return executeMCPTool(ctx, srv.ServerID, tc, runtimeConfig)
+	}
+
 	// Execute external tool
-	return executeExternalTool(tc, runtimeConfig)
+	return executeExternalTool(ctx, tc, runtimeConfig)
+}
+
+// executeMCPTool invokes the tool through an MCP tools/call request
+func executeMCPTool(ctx context.Context, serverID string, tc *chat.ToolCall, config *server.RuntimeConfig) (string, bool) {
+	client, err := mcpprotocol.Connect(ctx, serverID, config)
+	if err != nil {
+		return fmt.Sprintf("Failed to connect to MCP server '%s': %v", serverID, err), true
+	}
+
+	result, err := client.CallTool(ctx, tc.Handler, tc.Parameters)
+	if err != nil {
+		return fmt.Sprintf("MCP tools/call '%s' failed: %v", tc.Handler, err), true
+	}
+
+	return mcpprotocol.ResultText(result), result.IsError
 }
 
 // executeExternalTool makes HTTP request to external server, completely language agnostic
-func executeExternalTool(tc *chat.ToolCall, config *server.RuntimeConfig) (string, bool) {
+func executeExternalTool(ctx context.Context, tc *chat.ToolCall, config *server.RuntimeConfig) (string, bool) {
 
 	// Marshal parameters
 	jsonData, err := json.Marshal(tc.Parameters) // Fixed: tc.Parameters not tc.params
@@ -91,7 +117,7 @@ func executeExternalTool(tc *chat.ToolCall, config *server.RuntimeConfig) (strin
 
 	// Make HTTP request to handler route
 	url := fmt.Sprintf("http://localhost:%d/execute/%s", config.Port, tc.Handler)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Sprintf("Failed to create request: %v", err), true
 	}
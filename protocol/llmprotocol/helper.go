@@ -55,15 +55,15 @@ type ToolInfo struct {
 func ExtractTools(ag *agent.Agent) map[string]ToolInfo {
 	tools := make(map[string]ToolInfo)
 
-	for serverID, server := range ag.Registry.Servers {
-		for _, tool := range server.Tools {
+	for _, server := range ag.Registry.ListServers() {
+		for _, tool := range server.ListTools() {
 
 			schemaBytes, _ := json.Marshal(tool.InputSchema)
 			var schemaMap map[string]interface{}
 			json.Unmarshal(schemaBytes, &schemaMap)
 
 			tools[tool.ToolID] = ToolInfo{
-				ServerID:    serverID,
+				ServerID:    server.ServerID,
 				Description: tool.Description,
 				Schema:      schemaMap,
 				Handler:     tool.Handler,
@@ -0,0 +1,164 @@
+package llmprotocol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/modelcatalog"
+)
+
+// maxTranscriptResult caps how much of each tool result goes into a summary request
+const maxTranscriptResult = 2000
+
+const summaryInstructions = `You maintain a running summary of a conversation between a user and an AI assistant that uses tools.
+Merge the new part of the conversation into the existing summary. Keep the user's goals and preferences, decisions made, facts learned from tool results (names, IDs, numbers, file paths) and anything still unresolved. Drop pleasantries and repetition.
+Reply with the updated summary only, as short paragraphs or bullet points.`
+
+// withSummary appends the chat's running summary to the system prompt
+func withSummary(system, summary string) string {
+	if summary == "" {
+		return system
+	}
+	return system + "\n\nSummary of the earlier conversation:\n" + summary
+}
+
+// fitHistory makes the chat fit the history token limit before a call by
+// summarizing or dropping its oldest turns, and updates req.System with the
+// summary. Only a cancelled ctx is returned as an error; a failed summary
+// falls back to dropping the turns.
+func fitHistory(ctx context.Context, adapter Adapter, c *chat.Chat, ag *agent.Agent, req *Request, system string) error {
+	req.System = withSummary(system, c.GetSummary())
+
+	drop := c.TrimPoint(historyTokenLimit(ag, req))
+	if drop == 0 {
+		return nil
+	}
+
+	if ag.History.Summarize {
+		if err := summarizeOldest(ctx, adapter, c, ag, drop); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Summarizing history failed, dropping %d messages instead: %v", drop, err)
+		}
+	}
+	c.DropOldest(drop)
+	req.System = withSummary(system, c.GetSummary())
+
+	// A longer summary can push the history back over the limit
+	c.TrimToTokens(historyTokenLimit(ag, req))
+	return nil
+}
+
+// historyTokenLimit is the configured history limit, or what is left of the
+// smallest context window in the agent's fallback chain after the reply,
+// system prompt and tools, so the history fits whichever model answers; 0
+// means no limit is known
+func historyTokenLimit(ag *agent.Agent, req *Request) int {
+	if ag.History.MaxTokens > 0 {
+		return ag.History.MaxTokens
+	}
+	if ag.LLMConfig == nil {
+		return 0
+	}
+
+	available := windowLeft(ag.LLMConfig)
+	for _, fallback := range ag.LLMConfig.Fallbacks {
+		if left := windowLeft(&fallback.LLM); left > 0 && (available == 0 || left < available) {
+			available = left
+		}
+	}
+	if available == 0 {
+		return 0
+	}
+
+	tools, _ := json.Marshal(req.Tools)
+	limit := available - chat.EstimateTextTokens(req.System) - chat.EstimateTextTokens(string(tools))
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// windowLeft is the model's context window less its reply, or 0 when the
+// catalog doesn't know the window
+func windowLeft(llm *agent.LLMConfig) int {
+	model, ok := modelcatalog.Lookup(llm.Model)
+	if !ok || model.ContextWindow <= 0 {
+		return 0
+	}
+	if left := model.ContextWindow - llm.MaxTokens; left > 0 {
+		return left
+	}
+	return 1
+}
+
+// summarizeOldest merges the first n messages into the chat's summary with
+// one call to the summary model
+func summarizeOldest(ctx context.Context, adapter Adapter, c *chat.Chat, ag *agent.Agent, n int) error {
+	oldest := c.GetMessages()[:n]
+	prompt := "Conversation:\n\n" + transcript(oldest)
+	if summary := c.GetSummary(); summary != "" {
+		prompt = "Existing summary:\n\n" + summary + "\n\nNew part of the conversation:\n\n" + transcript(oldest)
+	}
+
+	resp, err := adapter.Complete(ctx, &Request{
+		System:   summaryInstructions,
+		Messages: []chat.Message{{Role: chat.RoleUser, Blocks: []chat.ContentBlock{chat.TextBlock(prompt)}}},
+		Model:    ag.History.SummaryModel,
+	})
+	if err != nil {
+		return err
+	}
+
+	if usage := priceUsage(resp.Usage); usage != nil {
+		c.RecordUsage(*usage)
+		ag.RecordUsage(*usage)
+	}
+
+	summary := strings.TrimSpace(chat.Message{Blocks: resp.Blocks}.Text())
+	if summary == "" {
+		return errors.New("model returned an empty summary")
+	}
+	c.SetSummary(summary)
+	return nil
+}
+
+// transcript renders messages as plain text for the summary model
+func transcript(messages []chat.Message) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		for _, block := range msg.Blocks {
+			switch {
+			case block.Type == chat.BlockText && block.Text != "":
+				role := "User"
+				if msg.Role == chat.RoleAssistant {
+					role = "Assistant"
+				}
+				fmt.Fprintf(&b, "%s: %s\n\n", role, block.Text)
+			case block.Type == chat.BlockToolCall && block.ToolCall != nil:
+				params, _ := json.Marshal(block.ToolCall.Parameters)
+				fmt.Fprintf(&b, "Assistant called %s with %s\n\n", block.ToolCall.ToolID, params)
+			case block.Type == chat.BlockToolResult && block.ToolResult != nil:
+				content := block.ToolResult.Content
+				if len(content) > maxTranscriptResult {
+					content = content[:maxTranscriptResult] + "..."
+				}
+				status := "returned"
+				if block.ToolResult.IsError {
+					status = "failed"
+				}
+				fmt.Fprintf(&b, "Tool %s %s: %s\n\n", block.ToolResult.ToolID, status, content)
+			case block.Type == chat.BlockImage:
+				b.WriteString("[image]\n\n")
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
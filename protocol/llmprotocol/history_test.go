@@ -0,0 +1,90 @@
+package llmprotocol
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+)
+
+func TestHistoryLimitFitsSmallestFallback(t *testing.T) {
+	ag := newTestAgent()
+	req := &Request{}
+
+	alone := historyTokenLimit(ag, req)
+
+	ag.LLMConfig.Fallbacks = []agent.FallbackConfig{
+		{LLM: agent.LLMConfig{Model: "gpt-3.5-turbo", MaxTokens: 100}},
+		{LLM: agent.LLMConfig{Model: "not-in-the-catalog", MaxTokens: 100}},
+	}
+	// The request's share is the same for every model, so only the windows differ
+	if got, want := historyTokenLimit(ag, req), alone-(128000-16385); got != want {
+		t.Fatalf("limit with a gpt-3.5-turbo fallback = %d, want %d", got, want)
+	}
+}
+
+// failingAdapter fails every call, counting them
+type failingAdapter struct {
+	calls int
+}
+
+func (a *failingAdapter) Complete(ctx context.Context, req *Request) (*Response, error) {
+	a.calls++
+	return nil, errors.New("summary model unavailable")
+}
+
+func TestFailedSummaryFallsBackToDroppingTurns(t *testing.T) {
+	ag := newTestAgent()
+	ag.History.Summarize = true
+	ag.History.MaxTokens = 200
+
+	c := chat.NewChat("test", 0)
+	for i := 0; i < 5; i++ {
+		c.AddUserMessage(strings.Repeat("question ", 40))
+		c.AddAssistantMessage(chat.TextBlock(strings.Repeat("answer ", 40)))
+	}
+	c.AddUserMessage("latest")
+
+	adapter := &failingAdapter{}
+	req := &Request{}
+	if err := fitHistory(context.Background(), adapter, c, ag, req, "system"); err != nil {
+		t.Fatalf("fitHistory = %v, want nil", err)
+	}
+
+	if adapter.calls != 1 {
+		t.Fatalf("summary model called %d times, want 1", adapter.calls)
+	}
+	if c.GetSummary() != "" || req.System != "system" {
+		t.Fatalf("summary = %q, system = %q; want neither changed", c.GetSummary(), req.System)
+	}
+	if got := c.EstimateTokens(); got > ag.History.MaxTokens {
+		t.Fatalf("history is %d tokens, want at most %d", got, ag.History.MaxTokens)
+	}
+	messages := c.GetMessages()
+	if messages[0].Role != chat.RoleUser || messages[len(messages)-1].Text() != "latest" {
+		t.Fatalf("history after dropping = %+v, want whole turns ending with the latest", messages)
+	}
+}
+
+func TestCancelledSummaryKeepsHistory(t *testing.T) {
+	ag := newTestAgent()
+	ag.History.Summarize = true
+	ag.History.MaxTokens = 50
+
+	c := chat.NewChat("test", 0)
+	c.AddUserMessage(strings.Repeat("question ", 40))
+	c.AddAssistantMessage(chat.TextBlock("answer"))
+	c.AddUserMessage("latest")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := fitHistory(ctx, &failingAdapter{}, c, ag, &Request{}, "system"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("fitHistory = %v, want context.Canceled", err)
+	}
+	if got := c.MessageCount(); got != 3 {
+		t.Fatalf("chat has %d messages, want all 3 kept", got)
+	}
+}
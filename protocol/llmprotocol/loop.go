@@ -0,0 +1,332 @@
+package llmprotocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/infrageneration"
+	"github.com/AnthonyL103/GOMCP/modelcatalog"
+	"github.com/AnthonyL103/GOMCP/servergeneration"
+)
+
+// Stop reasons reported by adapters, mapped from each provider's own values
+const (
+	StopEndTurn   = "end_turn"
+	StopToolUse   = "tool_use"
+	StopMaxTokens = "max_tokens"
+)
+
+// ToolSpec is a tool definition as offered to the model
+type ToolSpec struct {
+	Name        string
+	Description string
+	InputSchema map[string]interface{} // JSON Schema with "type": "object"
+}
+
+// Request is one provider-neutral LLM call
+type Request struct {
+	System   string
+	Messages []chat.Message
+	Tools    []ToolSpec
+
+	// Model overrides the adapter's configured model for this call, e.g. a
+	// cheaper one for summaries; empty uses the configured model
+	Model string
+
+	// OnEvent receives stream events; nil means a non-streamed call
+	OnEvent StreamHandler
+}
+
+// Response is the model's reply to a Request
+type Response struct {
+	Blocks     []chat.ContentBlock
+	StopReason string
+	// Usage holds the model and token counts; RunTurn fills in the cost
+	Usage *chat.Usage
+}
+
+// ModelOr returns the model the call should use, given the adapter's configured one
+func (r *Request) ModelOr(configured string) string {
+	if r.Model != "" {
+		return r.Model
+	}
+	return configured
+}
+
+// Adapter encodes a Request into a provider's wire format, sends it, and
+// decodes the reply. Everything else about a turn is handled by RunTurn.
+type Adapter interface {
+	Complete(ctx context.Context, req *Request) (*Response, error)
+}
+
+// Hooks are optional callbacks fired while RunTurn executes tools
+type Hooks struct {
+	OnToolStart  func(call chat.ToolCall)
+	OnToolResult func(call chat.ToolCall, result chat.ToolResult)
+}
+
+// ErrBudgetExceeded is returned when a turn stops at one of the agent's budget limits
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// RunTurn adds userMessage to the chat and runs the agent loop: call the
+// model, execute any tool calls it makes, and repeat until it answers
+// without tools. onEvent may be nil for a non-streamed turn. Before every
+// LLM call the agent's budget is checked and the history is fitted to the
+// model's context window. A turn that fails or stops at a budget limit is
+// removed from the chat again, user message included.
+func RunTurn(ctx context.Context, adapter Adapter, c *chat.Chat, ag *agent.Agent, userMessage string, hooks Hooks, onEvent StreamHandler) (err error) {
+	if err := checkBudget(c, ag); err != nil {
+		return err
+	}
+
+	c.AddUserMessage(userMessage)
+	defer func() {
+		if err != nil {
+			c.DropLatestTurn()
+		}
+	}()
+
+	availableTools := ExtractTools(ag)
+	system := GetAgentInstructions(ag)
+	req := &Request{
+		Tools:   turnTools(availableTools, ag),
+		OnEvent: onEvent,
+	}
+
+	for iteration := 1; ; iteration++ {
+		if limit := ag.Budget.MaxToolIterations; limit > 0 && iteration > limit {
+			return fmt.Errorf("%w: stopped after %d LLM calls in one turn (max_tool_iterations)", ErrBudgetExceeded, limit)
+		}
+		if iteration > 1 {
+			if err := checkBudget(c, ag); err != nil {
+				return err
+			}
+		}
+
+		if err := fitHistory(ctx, adapter, c, ag, req, system); err != nil {
+			return err
+		}
+
+		req.Messages = c.GetMessages()
+		resp, err := adapter.Complete(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		usage := priceUsage(resp.Usage)
+		if usage != nil {
+			c.RecordUsage(*usage)
+			ag.RecordUsage(*usage)
+		}
+
+		toolCalls := []*chat.ToolCall{}
+		for _, block := range resp.Blocks {
+			if block.Type == chat.BlockToolCall && block.ToolCall != nil {
+				toolCalls = append(toolCalls, block.ToolCall)
+			}
+		}
+
+		if len(toolCalls) == 0 || resp.StopReason == StopMaxTokens {
+			// A reply cut off mid tool call can't be answered, so only its
+			// text is kept
+			blocks := withoutToolCalls(resp.Blocks)
+			if len(blocks) > 0 {
+				c.AddAssistantReply(usage, blocks...)
+			}
+			return nil
+		}
+
+		// A call to a tool the agent can't use is answered with an error
+		// result, so the model can try again; the resolved server and handler
+		// are stored on the blocks
+		outputs := make([]ToolOutput, len(toolCalls))
+		runnable := []*chat.ToolCall{}
+		runnableIndex := []int{}
+		for i, call := range toolCalls {
+			if err := resolveToolCall(availableTools, ag, call); err != nil {
+				outputs[i] = ToolOutput{Content: err.Error(), IsError: true}
+				continue
+			}
+			runnable = append(runnable, call)
+			runnableIndex = append(runnableIndex, i)
+
+			if hooks.OnToolStart != nil {
+				hooks.OnToolStart(*call)
+			}
+			log.Printf("Calling tool %s with params: %v", call.ToolID, truncateParams(call.Parameters))
+		}
+
+		for j, output := range ExecuteTools(ctx, ag, runnable) {
+			outputs[runnableIndex[j]] = output
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		results := make([]chat.ToolResult, len(toolCalls))
+		for i, call := range toolCalls {
+			results[i] = chat.ToolResult{
+				ServerID:  call.ServerID,
+				ToolID:    call.ToolID,
+				Content:   outputs[i].Content,
+				IsError:   outputs[i].IsError,
+				ToolUseID: call.ToolUseID,
+			}
+
+			if hooks.OnToolResult != nil {
+				hooks.OnToolResult(*call, results[i])
+			}
+		}
+
+		// Save the turn only once every call has a result, so history never
+		// holds a tool call without its result
+		c.AddAssistantReply(usage, resp.Blocks...)
+		c.AddToolResults(results...)
+	}
+}
+
+// NextRequest is the request RunTurn would send for the chat as it stands,
+// before the history is fitted to the context window
+func NextRequest(c *chat.Chat, ag *agent.Agent) *Request {
+	return &Request{
+		System:   withSummary(GetAgentInstructions(ag), c.GetSummary()),
+		Messages: c.GetMessages(),
+		Tools:    turnTools(ExtractTools(ag), ag),
+	}
+}
+
+// turnTools lists the tools offered to the agent's model; models without
+// function calling reject requests that offer tools, so they get none
+func turnTools(availableTools map[string]ToolInfo, ag *agent.Agent) []ToolSpec {
+	if ag.LLMConfig != nil && !modelcatalog.SupportsTools(ag.LLMConfig.Model) {
+		return nil
+	}
+	return BuildToolSpecs(availableTools, ag)
+}
+
+// checkBudget fails once the session's tokens or the agent's spend for the
+// day have reached their limits
+func checkBudget(c *chat.Chat, ag *agent.Agent) error {
+	if limit := ag.Budget.MaxSessionTokens; limit > 0 {
+		if used := c.GetTotalUsage().TotalTokens(); used >= limit {
+			return fmt.Errorf("%w: session used %d of %d tokens (max_session_tokens)", ErrBudgetExceeded, used, limit)
+		}
+	}
+
+	if limit := ag.Budget.MaxDailyCostUSD; limit > 0 {
+		if spent := ag.DailyUsage().CostUSD; spent >= limit {
+			return fmt.Errorf("%w: agent spent $%.2f of $%.2f today (max_daily_cost_usd)", ErrBudgetExceeded, spent, limit)
+		}
+	}
+
+	return nil
+}
+
+// priceUsage counts one call and prices it from the model catalog
+func priceUsage(reported *chat.Usage) *chat.Usage {
+	if reported == nil {
+		return nil
+	}
+
+	usage := *reported
+	usage.Calls = 1
+	cost, ok := modelcatalog.Cost(usage.Model, usage.InputTokens, usage.OutputTokens, usage.CacheReadTokens, usage.CacheWriteTokens)
+	usage.CostUSD = cost
+	usage.Unpriced = !ok
+	return &usage
+}
+
+// BuildToolSpecs lists the registry tools, sorted by name so requests are
+// stable, followed by the generation tools the agent has enabled
+func BuildToolSpecs(availableTools map[string]ToolInfo, ag *agent.Agent) []ToolSpec {
+	names := make([]string, 0, len(availableTools))
+	for name := range availableTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	specs := make([]ToolSpec, 0, len(names))
+	for _, name := range names {
+		toolInfo := availableTools[name]
+		schema := map[string]interface{}{}
+		for k, v := range toolInfo.Schema {
+			schema[k] = v
+		}
+		schema["type"] = "object"
+
+		specs = append(specs, ToolSpec{
+			Name:        name,
+			Description: toolInfo.Description,
+			InputSchema: schema,
+		})
+	}
+
+	if ag.ServerGeneration {
+		for _, def := range servergeneration.SharedServerGenerationToolDefinitions() {
+			specs = append(specs, ToolSpec{Name: def.Name, Description: def.Description, InputSchema: def.InputSchema})
+		}
+	}
+
+	if ag.InfraGeneration {
+		for _, def := range infrageneration.SharedAWSToolDefinitions() {
+			specs = append(specs, ToolSpec{Name: def.Name, Description: def.Description, InputSchema: def.InputSchema})
+		}
+	}
+
+	return specs
+}
+
+// resolveToolCall fills in the server and handler for a call, rejecting
+// unknown tools and generation tools the agent hasn't enabled
+func resolveToolCall(availableTools map[string]ToolInfo, ag *agent.Agent, call *chat.ToolCall) error {
+	isServerGeneration := servergeneration.IsServerGenerationTool(call.ToolID)
+	isInfraGeneration := infrageneration.IsInfraGenerationTool(call.ToolID)
+
+	toolInfo, exists := availableTools[call.ToolID]
+	if !exists {
+		if !isServerGeneration && !isInfraGeneration {
+			return fmt.Errorf("tool %s not found", call.ToolID)
+		}
+		toolInfo = ToolInfo{ServerID: "infrastructure_generation", Handler: call.ToolID}
+	}
+
+	if isServerGeneration && !ag.ServerGeneration {
+		return fmt.Errorf("tool %s not available; enable server generation in config", call.ToolID)
+	}
+
+	if isInfraGeneration && !ag.InfraGeneration {
+		return fmt.Errorf("tool %s not available; enable infra generation in config", call.ToolID)
+	}
+
+	call.ServerID = toolInfo.ServerID
+	call.Handler = toolInfo.Handler
+	return nil
+}
+
+// truncateParams shortens long string parameters for logging
+func truncateParams(params map[string]interface{}) map[string]interface{} {
+	truncated := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if strVal, ok := v.(string); ok && len(strVal) > 100 {
+			truncated[k] = strVal[:100] + "..."
+		} else {
+			truncated[k] = v
+		}
+	}
+	return truncated
+}
+
+func withoutToolCalls(blocks []chat.ContentBlock) []chat.ContentBlock {
+	kept := make([]chat.ContentBlock, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Type != chat.BlockToolCall {
+			kept = append(kept, block)
+		}
+	}
+	return kept
+}
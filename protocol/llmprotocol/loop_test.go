@@ -0,0 +1,105 @@
+package llmprotocol
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/registry"
+)
+
+// scriptedAdapter answers each call with the next response in turn, and
+// records the requests it was sent
+type scriptedAdapter struct {
+	responses []*Response
+	requests  []*Request
+}
+
+func (a *scriptedAdapter) Complete(ctx context.Context, req *Request) (*Response, error) {
+	a.requests = append(a.requests, &Request{System: req.System, Messages: req.Messages, Model: req.Model})
+	if len(a.responses) == 0 {
+		return nil, errors.New("no response scripted")
+	}
+	resp := a.responses[0]
+	a.responses = a.responses[1:]
+	return resp, nil
+}
+
+func newTestAgent() *agent.Agent {
+	return agent.NewAgent("tester", "Test agent", registry.NewRegistry(), &agent.LLMConfig{
+		APIKey:      "test",
+		Model:       "gpt-4o",
+		Temperature: 0.5,
+		MaxTokens:   100,
+	}, false, false, false)
+}
+
+func toolCallResponse(toolID, useID string) *Response {
+	return &Response{
+		Blocks:     []chat.ContentBlock{chat.ToolCallBlock(chat.ToolCall{ToolID: toolID, ToolUseID: useID})},
+		StopReason: StopToolUse,
+	}
+}
+
+func textResponse(text string) *Response {
+	return &Response{Blocks: []chat.ContentBlock{chat.TextBlock(text)}, StopReason: StopEndTurn}
+}
+
+func TestUnknownToolIsAnsweredWithError(t *testing.T) {
+	adapter := &scriptedAdapter{responses: []*Response{
+		toolCallResponse("no_such_tool", "call_1"),
+		textResponse("Sorry, that tool doesn't exist."),
+	}}
+	c := chat.NewChat("test", 0)
+
+	if err := RunTurn(context.Background(), adapter, c, newTestAgent(), "hi", Hooks{}, nil); err != nil {
+		t.Fatalf("RunTurn = %v, want nil", err)
+	}
+
+	messages := c.GetMessages()
+	if len(messages) != 4 {
+		t.Fatalf("chat has %d messages, want 4", len(messages))
+	}
+	results := messages[2].ToolResults()
+	if len(results) != 1 || !results[0].IsError || results[0].ToolUseID != "call_1" {
+		t.Fatalf("tool results = %+v, want one error result for call_1", results)
+	}
+	if len(adapter.requests) != 2 {
+		t.Fatalf("model called %d times, want 2", len(adapter.requests))
+	}
+}
+
+func TestStoppedTurnIsRemoved(t *testing.T) {
+	ag := newTestAgent()
+	ag.Budget.MaxToolIterations = 1
+	adapter := &scriptedAdapter{responses: []*Response{toolCallResponse("no_such_tool", "call_1")}}
+	c := chat.NewChat("test", 0)
+	c.AddUserMessage("earlier")
+	c.AddAssistantMessage(chat.TextBlock("reply"))
+
+	err := RunTurn(context.Background(), adapter, c, ag, "hi", Hooks{}, nil)
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("RunTurn = %v, want ErrBudgetExceeded", err)
+	}
+	if got := c.MessageCount(); got != 2 {
+		t.Fatalf("chat has %d messages after the stopped turn, want 2", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := RunTurn(ctx, cancelledAdapter{}, c, ag, "hi", Hooks{}, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("RunTurn = %v, want context.Canceled", err)
+	}
+	if got := c.MessageCount(); got != 2 {
+		t.Fatalf("chat has %d messages after the cancelled turn, want 2", got)
+	}
+}
+
+// cancelledAdapter fails every call with its context's error
+type cancelledAdapter struct{}
+
+func (cancelledAdapter) Complete(ctx context.Context, req *Request) (*Response, error) {
+	return nil, ctx.Err()
+}
@@ -0,0 +1,70 @@
+package llmprotocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+)
+
+// ToolOutput is the result of one tool call
+type ToolOutput struct {
+	Content string
+	IsError bool
+}
+
+// ExecuteTools runs a turn's tool calls concurrently, at most
+// ag.MaxParallelTools at a time, each bounded by ag.ToolTimeout.
+// Results are returned in the same order as calls.
+func ExecuteTools(ctx context.Context, ag *agent.Agent, calls []*chat.ToolCall) []ToolOutput {
+	outputs := make([]ToolOutput, len(calls))
+
+	limit := ag.MaxParallelTools
+	if limit <= 0 {
+		limit = agent.DefaultMaxParallelTools
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, tc := range calls {
+		wg.Add(1)
+		go func(i int, tc *chat.ToolCall) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				outputs[i] = ToolOutput{Content: fmt.Sprintf("Tool '%s' cancelled: %v", tc.ToolID, ctx.Err()), IsError: true}
+				return
+			}
+
+			outputs[i] = executeWithTimeout(ctx, ag, tc)
+		}(i, tc)
+	}
+	wg.Wait()
+
+	return outputs
+}
+
+// executeWithTimeout runs one tool call under the agent's per-call timeout
+func executeWithTimeout(ctx context.Context, ag *agent.Agent, tc *chat.ToolCall) ToolOutput {
+	callCtx := ctx
+	if ag.ToolTimeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, ag.ToolTimeout)
+		defer cancel()
+	}
+
+	content, isError := ExecuteTool(callCtx, ag, tc)
+
+	// Report the timeout itself rather than whatever the aborted call returned
+	if ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		return ToolOutput{Content: fmt.Sprintf("Tool '%s' timed out after %s", tc.ToolID, ag.ToolTimeout), IsError: true}
+	}
+
+	return ToolOutput{Content: content, IsError: isError}
+}
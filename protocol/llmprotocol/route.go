@@ -0,0 +1,93 @@
+package llmprotocol
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+)
+
+// routeContextMessages is how much recent conversation the router sees, so
+// a short follow-up like "and the other one?" stays with the same agent
+const routeContextMessages = 6
+
+const routerInstructions = `You route each user message to the assistant best suited to answer it.
+Choose from the assistants below by their descriptions. A short follow-up belongs with whoever suits the topic of the recent conversation.
+Reply with the ID of exactly one assistant and nothing else.
+
+Assistants:
+`
+
+// RouteMessage asks the roster's router which agent should answer message,
+// given the recent conversation in c. Only a cancelled ctx or an exhausted
+// router budget is returned as an error; any other failure routes to the
+// default agent.
+func RouteMessage(ctx context.Context, adapter Adapter, roster *agent.Roster, c *chat.Chat, message string) (*agent.Agent, error) {
+	if err := checkBudget(c, roster.Router); err != nil {
+		return nil, err
+	}
+
+	ag, err := chooseAgent(ctx, adapter, roster, c, message)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("Routing failed, using default agent %s: %v", roster.Default.AgentID, err)
+		return roster.Default, nil
+	}
+	return ag, nil
+}
+
+func chooseAgent(ctx context.Context, adapter Adapter, roster *agent.Roster, c *chat.Chat, message string) (*agent.Agent, error) {
+	var system strings.Builder
+	system.WriteString(routerInstructions)
+	for _, ag := range roster.Agents {
+		fmt.Fprintf(&system, "- %s: %s\n", ag.AgentID, ag.Description)
+	}
+
+	prompt := "New message:\n\n" + message
+	if recent := c.GetRecentMessages(routeContextMessages); len(recent) > 0 {
+		prompt = "Recent conversation:\n\n" + transcript(recent) + "\n\n" + prompt
+	}
+
+	resp, err := adapter.Complete(ctx, &Request{
+		System:   system.String(),
+		Messages: []chat.Message{{Role: chat.RoleUser, Blocks: []chat.ContentBlock{chat.TextBlock(prompt)}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if usage := priceUsage(resp.Usage); usage != nil {
+		c.RecordUsage(*usage)
+		roster.Router.RecordUsage(*usage)
+	}
+
+	reply := chat.Message{Blocks: resp.Blocks}.Text()
+	if ag := matchAgent(roster, reply); ag != nil {
+		return ag, nil
+	}
+	return nil, fmt.Errorf("router replied %q, which names no agent", reply)
+}
+
+// matchAgent finds the agent a router reply names. Models sometimes quote
+// the ID or wrap it in a sentence, so an exact match is tried first and then
+// the longest ID the reply contains.
+func matchAgent(roster *agent.Roster, reply string) *agent.Agent {
+	reply = strings.ToLower(strings.Trim(strings.TrimSpace(reply), "\"'`.*"))
+
+	var best *agent.Agent
+	for _, ag := range roster.Agents {
+		id := strings.ToLower(ag.AgentID)
+		if reply == id {
+			return ag
+		}
+		if strings.Contains(reply, id) && (best == nil || len(id) > len(best.AgentID)) {
+			best = ag
+		}
+	}
+	return best
+}
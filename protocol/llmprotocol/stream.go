@@ -0,0 +1,25 @@
+package llmprotocol
+
+// Stream event types delivered to a StreamHandler
+const (
+	StreamText      = "text"
+	StreamToolInput = "tool_input"
+)
+
+// StreamEvent is one incremental piece of a streamed LLM response
+type StreamEvent struct {
+	Type string
+
+	// Text holds the new text for StreamText events
+	Text string
+
+	// Tool fields are set for StreamToolInput events. PartialJSON is a
+	// fragment of the tool arguments and is not valid JSON on its own.
+	ToolUseID   string
+	ToolName    string
+	PartialJSON string
+}
+
+// StreamHandler receives stream events as they arrive. It runs on the
+// request goroutine, so slow handlers slow down the stream.
+type StreamHandler func(event StreamEvent)
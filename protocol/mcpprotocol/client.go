@@ -0,0 +1,317 @@
+package mcpprotocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AnthonyL103/GOMCP/server"
+	"github.com/AnthonyL103/GOMCP/tool"
+)
+
+const (
+	clientName    = "gomcp"
+	clientVersion = "0.1.0"
+
+	defaultHTTPPath = "/mcp"
+
+	cancelNotifyTimeout = 2 * time.Second
+)
+
+// Client is an MCP client bound to a single server
+type Client struct {
+	ServerID   string
+	ServerInfo Implementation
+
+	transport Transport
+	nextID    int64
+
+	// mu guards the handshake; calls after it may run concurrently
+	mu          sync.Mutex
+	initialized bool
+}
+
+func NewClient(serverID string, transport Transport) *Client {
+	return &Client{
+		ServerID:  serverID,
+		transport: transport,
+	}
+}
+
+// Initialize performs the initialize / notifications/initialized handshake.
+// It is safe to call more than once; only the first call talks to the server.
+func (c *Client) Initialize(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.initialized {
+		return nil
+	}
+
+	params := InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      Implementation{Name: clientName, Version: clientVersion},
+	}
+
+	var result InitializeResult
+	if err := c.call(ctx, MethodInitialize, params, &result); err != nil {
+		return fmt.Errorf("initialize failed for server %s: %w", c.ServerID, err)
+	}
+
+	notification, err := NewNotification(MethodNotificationInitialized, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.transport.Notify(ctx, notification); err != nil {
+		return fmt.Errorf("initialized notification failed for server %s: %w", c.ServerID, err)
+	}
+
+	c.ServerInfo = result.ServerInfo
+	c.initialized = true
+	return nil
+}
+
+// ListTools returns every tool the server advertises, following pagination cursors
+func (c *Client) ListTools(ctx context.Context) ([]ToolDescriptor, error) {
+	if err := c.Initialize(ctx); err != nil {
+		return nil, err
+	}
+
+	tools := []ToolDescriptor{}
+	cursor := ""
+	for {
+		var result ListToolsResult
+		if err := c.call(ctx, MethodToolsList, ListToolsParams{Cursor: cursor}, &result); err != nil {
+			return nil, fmt.Errorf("tools/list failed for server %s: %w", c.ServerID, err)
+		}
+		tools = append(tools, result.Tools...)
+
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// CallTool invokes a tool by name through tools/call
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*CallToolResult, error) {
+	if err := c.Initialize(ctx); err != nil {
+		return nil, err
+	}
+
+	var result CallToolResult
+	if err := c.call(ctx, MethodToolsCall, CallToolParams{Name: name, Arguments: arguments}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Close() error {
+	return c.transport.Close()
+}
+
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	req, err := NewRequest(atomic.AddInt64(&c.nextID, 1), method, params)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.transport.Send(ctx, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			c.cancelRequest(req.ID, ctx.Err())
+		}
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("failed to parse %s result: %w", method, err)
+	}
+	return nil
+}
+
+// ResultText flattens tool output into the single string the LLM sees
+func ResultText(result *CallToolResult) string {
+	parts := make([]string, 0, len(result.Content))
+	for _, item := range result.Content {
+		switch item.Type {
+		case "text":
+			parts = append(parts, item.Text)
+		default:
+			parts = append(parts, fmt.Sprintf("[%s content %s]", item.Type, item.MimeType))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ClientManager keeps one live client per MCP server
+type ClientManager struct {
+	clients map[string]*Client
+	mu      sync.Mutex
+}
+
+var manager = &ClientManager{
+	clients: make(map[string]*Client),
+}
+
+// cancelRequest tells the server to stop working on an abandoned request.
+// The caller's context is already done, so this gets a short one of its own.
+func (c *Client) cancelRequest(id json.RawMessage, reason error) {
+	notification, err := NewNotification(MethodNotificationCancelled, map[string]interface{}{
+		"requestId": id,
+		"reason":    reason.Error(),
+	})
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cancelNotifyTimeout)
+	defer cancel()
+	c.transport.Notify(ctx, notification)
+}
+
+// Connect returns the client for a server, creating and initializing it on first use
+func Connect(ctx context.Context, serverID string, config *server.RuntimeConfig) (*Client, error) {
+	if config == nil {
+		return nil, fmt.Errorf("server %s has no runtime config", serverID)
+	}
+
+	manager.mu.Lock()
+	client, exists := manager.clients[serverID]
+	if !exists {
+		// Stdio clients only exist once the launcher has attached the pipes
+		if config.IsStdio() {
+			manager.mu.Unlock()
+			return nil, fmt.Errorf("stdio server %s is not running", serverID)
+		}
+		client = NewClient(serverID, NewHTTPTransport(endpointURL(config)))
+		manager.clients[serverID] = client
+	}
+	manager.mu.Unlock()
+
+	if err := client.Initialize(ctx); err != nil {
+		// A cancelled caller says nothing about the server; keep the client
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		Disconnect(serverID)
+		return nil, err
+	}
+	return client, nil
+}
+
+// Register installs a client built by the caller, e.g. one wrapping the pipes
+// of a launched stdio server. Any previous client for the server is closed.
+func Register(client *Client) {
+	manager.mu.Lock()
+	previous, exists := manager.clients[client.ServerID]
+	manager.clients[client.ServerID] = client
+	manager.mu.Unlock()
+
+	if exists && previous != client {
+		previous.Close()
+	}
+}
+
+// Disconnect closes and forgets the client for a server
+func Disconnect(serverID string) {
+	manager.mu.Lock()
+	client, exists := manager.clients[serverID]
+	delete(manager.clients, serverID)
+	manager.mu.Unlock()
+
+	if exists {
+		client.Close()
+	}
+}
+
+// DisconnectAll closes every open client
+func DisconnectAll() {
+	manager.mu.Lock()
+	ids := make([]string, 0, len(manager.clients))
+	for id := range manager.clients {
+		ids = append(ids, id)
+	}
+	manager.mu.Unlock()
+
+	for _, id := range ids {
+		Disconnect(id)
+	}
+}
+
+// DiscoverTools handshakes with a running server and converts its tools/list
+// result into registry tools. Handlers are the MCP tool names.
+func DiscoverTools(ctx context.Context, srv *server.MCPServer) ([]*tool.Tool, error) {
+	client, err := Connect(ctx, srv.ServerID, srv.RuntimeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptors, err := client.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tools := make([]*tool.Tool, 0, len(descriptors))
+	for _, d := range descriptors {
+		t, err := ToolFromDescriptor(d)
+		if err != nil {
+			return nil, fmt.Errorf("server %s advertised invalid tool '%s': %w", srv.ServerID, d.Name, err)
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// ToolFromDescriptor converts an MCP tool descriptor into a tool.Tool
+func ToolFromDescriptor(d ToolDescriptor) (*tool.Tool, error) {
+	var schema tool.JSONSchema
+	if d.InputSchema != nil {
+		schemaBytes, err := json.Marshal(d.InputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal input schema: %w", err)
+		}
+		if err := json.Unmarshal(schemaBytes, &schema); err != nil {
+			return nil, fmt.Errorf("unsupported input schema: %w", err)
+		}
+	}
+
+	description := d.Description
+	if strings.TrimSpace(description) == "" {
+		description = d.Name
+	}
+
+	cleanToolID, cleanDesc, cleanHandler, cleanSchema, err := tool.ValidateToolConfig(d.Name, description, d.Name, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tool.Tool{
+		ToolID:      cleanToolID,
+		Description: cleanDesc,
+		InputSchema: cleanSchema,
+		Handler:     cleanHandler,
+	}, nil
+}
+
+func endpointURL(config *server.RuntimeConfig) string {
+	path := strings.TrimSpace(config.Path)
+	if path == "" {
+		path = defaultHTTPPath
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("http://localhost:%d%s", config.Port, path)
+}
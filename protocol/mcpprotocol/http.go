@@ -0,0 +1,186 @@
+package mcpprotocol
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionHeader carries the session ID a streamable HTTP server assigns at
+// initialize
+const SessionHeader = "Mcp-Session-Id"
+
+// Transport carries JSON-RPC messages between the client and an MCP server
+type Transport interface {
+	// Send delivers a request and waits for the matching response, giving
+	// up when ctx is done
+	Send(ctx context.Context, req *Request) (*Response, error)
+
+	// Notify delivers a notification without waiting for a response
+	Notify(ctx context.Context, req *Request) error
+
+	Close() error
+}
+
+// HTTPTransport speaks the MCP streamable HTTP transport: every message is
+// POSTed to a single endpoint and the server answers with either a JSON body
+// or an SSE stream that ends with the response.
+type HTTPTransport struct {
+	URL    string
+	client *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+func NewHTTPTransport(url string) *HTTPTransport {
+	return &HTTPTransport{
+		URL:    url,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (t *HTTPTransport) Send(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("MCP server error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return readSSEResponse(resp.Body, req.ID)
+	}
+
+	var response Response
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to parse MCP response: %w", err)
+	}
+	return &response, nil
+}
+
+func (t *HTTPTransport) Notify(ctx context.Context, req *Request) error {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("MCP server rejected %s (status %d): %s", req.Method, resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.sessionID = ""
+	t.mu.Unlock()
+
+	if sessionID == "" {
+		return nil
+	}
+
+	// Servers that issued a session may release it early on DELETE
+	req, err := http.NewRequest("DELETE", t.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(SessionHeader, sessionID)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (t *HTTPTransport) post(ctx context.Context, msg *Request) (*http.Response, error) {
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", msg.Method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.URL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set(SessionHeader, t.sessionID)
+	}
+	t.mu.Unlock()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s to %s: %w", msg.Method, t.URL, err)
+	}
+
+	if sessionID := resp.Header.Get(SessionHeader); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+
+	return resp, nil
+}
+
+// readSSEResponse scans an event stream until the response matching id arrives.
+// Server-initiated notifications sent on the same stream are skipped.
+func readSSEResponse(body io.Reader, id json.RawMessage) (*Response, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		}
+
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		// Blank line terminates an event
+		var response Response
+		err := json.Unmarshal([]byte(data.String()), &response)
+		data.Reset()
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(response.ID, id) {
+			return &response, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MCP event stream: %w", err)
+	}
+
+	// Stream may end without the trailing blank line
+	var response Response
+	if data.Len() > 0 && json.Unmarshal([]byte(data.String()), &response) == nil && bytes.Equal(response.ID, id) {
+		return &response, nil
+	}
+	return nil, fmt.Errorf("MCP event stream closed before response %s", string(id))
+}
@@ -0,0 +1,148 @@
+package mcpprotocol
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	JSONRPCVersion = "2.0"
+
+	// ProtocolVersion is the MCP revision this client negotiates during initialize.
+	ProtocolVersion = "2025-03-26"
+)
+
+// SupportedProtocolVersions lists the MCP revisions we can speak, newest first
+var SupportedProtocolVersions = []string{ProtocolVersion, "2024-11-05"}
+
+// Standard JSON-RPC 2.0 error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// MCP method names
+const (
+	MethodInitialize              = "initialize"
+	MethodNotificationInitialized = "notifications/initialized"
+	MethodNotificationCancelled   = "notifications/cancelled"
+	MethodPing                    = "ping"
+	MethodToolsList               = "tools/list"
+	MethodToolsCall               = "tools/call"
+)
+
+// Request is a JSON-RPC 2.0 request. A request without an ID is a notification.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC 2.0 response carrying either a result or an error.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is the error object of a failed JSON-RPC call.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// IsNotification reports whether the request expects no response.
+func (r *Request) IsNotification() bool {
+	return len(r.ID) == 0
+}
+
+// NewRequest builds a request with the given numeric ID and marshalled params.
+func NewRequest(id int64, method string, params interface{}) (*Request, error) {
+	req, err := NewNotification(method, params)
+	if err != nil {
+		return nil, err
+	}
+	req.ID = json.RawMessage(fmt.Sprintf("%d", id))
+	return req, nil
+}
+
+// NewNotification builds a request without an ID.
+func NewNotification(method string, params interface{}) (*Request, error) {
+	req := &Request{
+		JSONRPC: JSONRPCVersion,
+		Method:  method,
+	}
+
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal params for %s: %w", method, err)
+		}
+		req.Params = data
+	}
+
+	return req, nil
+}
+
+// Implementation identifies a client or server during the handshake
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type InitializeParams struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ClientInfo      Implementation         `json:"clientInfo"`
+}
+
+type InitializeResult struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ServerInfo      Implementation         `json:"serverInfo"`
+	Instructions    string                 `json:"instructions,omitempty"`
+}
+
+// ToolDescriptor is a single entry of a tools/list result
+type ToolDescriptor struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+type ListToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ListToolsResult struct {
+	Tools      []ToolDescriptor `json:"tools"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+type CallToolParams struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// ContentItem is one piece of tool output (text, image, resource, ...)
+type ContentItem struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+type CallToolResult struct {
+	Content []ContentItem `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
@@ -0,0 +1,181 @@
+package mcpprotocol
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// StdioTransport speaks MCP over a child process's stdin/stdout. Messages are
+// newline-delimited JSON; responses are matched to requests by ID so several
+// calls can be in flight at once.
+type StdioTransport struct {
+	writer  io.WriteCloser
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan *Response
+	readErr error
+	done    chan struct{}
+}
+
+func NewStdioTransport(reader io.Reader, writer io.WriteCloser) *StdioTransport {
+	t := &StdioTransport{
+		writer:  writer,
+		pending: make(map[string]chan *Response),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop(reader)
+	return t
+}
+
+func (t *StdioTransport) Send(ctx context.Context, req *Request) (*Response, error) {
+	ch := make(chan *Response, 1)
+	key := string(req.ID)
+
+	t.mu.Lock()
+	if t.readErr != nil {
+		err := t.readErr
+		t.mu.Unlock()
+		return nil, err
+	}
+	t.pending[key] = ch
+	t.mu.Unlock()
+
+	if err := t.write(req); err != nil {
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-ctx.Done():
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+		return nil, ctx.Err()
+	case <-t.done:
+		// The reply may have raced the server exiting
+		select {
+		case resp := <-ch:
+			return resp, nil
+		default:
+		}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.pending, key)
+		return nil, t.readErr
+	}
+}
+
+func (t *StdioTransport) Notify(ctx context.Context, req *Request) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return t.write(req)
+}
+
+// Done is closed once the server's stdout ends, after the last message on it
+// has been read
+func (t *StdioTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// Close closes the child's stdin, which MCP servers treat as a shutdown request
+func (t *StdioTransport) Close() error {
+	return t.writer.Close()
+}
+
+func (t *StdioTransport) write(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	data = append(data, '\n')
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.writer.Write(data); err != nil {
+		return fmt.Errorf("failed to write to server stdin: %w", err)
+	}
+	return nil
+}
+
+func (t *StdioTransport) readLoop(reader io.Reader) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		// Peek at the shape: anything with a method is server-initiated
+		var envelope struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.Unmarshal(line, &envelope); err != nil {
+			log.Printf("Ignoring non JSON-RPC output from MCP server: %s", string(line))
+			continue
+		}
+
+		if envelope.Method != "" {
+			t.handleServerRequest(envelope.ID, envelope.Method)
+			continue
+		}
+
+		var response Response
+		if err := json.Unmarshal(line, &response); err != nil {
+			log.Printf("Ignoring malformed MCP response: %v", err)
+			continue
+		}
+
+		t.mu.Lock()
+		ch, exists := t.pending[string(response.ID)]
+		delete(t.pending, string(response.ID))
+		t.mu.Unlock()
+
+		if exists {
+			ch <- &response
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+
+	t.mu.Lock()
+	t.readErr = fmt.Errorf("MCP server stdout closed: %w", err)
+	t.mu.Unlock()
+	close(t.done)
+}
+
+// handleServerRequest answers requests the server sends to us. Notifications
+// (no ID) need no reply; ping gets an empty result and everything else is
+// reported as unsupported.
+func (t *StdioTransport) handleServerRequest(id json.RawMessage, method string) {
+	if len(id) == 0 {
+		return
+	}
+
+	response := Response{JSONRPC: JSONRPCVersion, ID: id}
+	if method == MethodPing {
+		response.Result = json.RawMessage("{}")
+	} else {
+		response.Error = &RPCError{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %s not supported by client", method)}
+	}
+
+	if err := t.write(response); err != nil {
+		log.Printf("Failed to answer MCP server request %s: %v", method, err)
+	}
+}
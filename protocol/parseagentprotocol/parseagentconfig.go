@@ -2,19 +2,32 @@ package parseagentprotocol
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
 	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/modelcatalog"
 	"github.com/AnthonyL103/GOMCP/protocol/parseserverprotocol"
 	"github.com/AnthonyL103/GOMCP/registry"
+	"github.com/AnthonyL103/GOMCP/server"
 )
 
 // AgentConfig represents the root YAML structure
 type AgentConfig struct {
-	Agents []AgentDefinition `yaml:"agents"`
+	Agents       []AgentDefinition `yaml:"agents"`
+	DefaultAgent string            `yaml:"default_agent"` // agent_id used when none is chosen, default the first
+	Router       *RouterYAML       `yaml:"router"`        // picks an agent for each message when set
+}
+
+// RouterYAML configures the router agent. An empty llm block reuses the
+// default agent's model; unset settings come from it as for fallbacks.
+type RouterYAML struct {
+	LLM LLMConfigYAML `yaml:"llm"`
 }
 
 // AgentDefinition represents a single agent
@@ -26,6 +39,26 @@ type AgentDefinition struct {
 	ServerGeneration bool          `yaml:"server_generation"`
 	VoiceChat        bool          `yaml:"voice_chat"`
 	InfraGeneration  bool          `yaml:"infra_generation"`
+	MaxParallelTools int           `yaml:"max_parallel_tools"`   // 0 uses the default
+	ToolTimeout      int           `yaml:"tool_timeout_seconds"` // 0 uses the default
+	Budget           BudgetYAML    `yaml:"budget"`
+	History          HistoryYAML   `yaml:"history"`
+}
+
+// HistoryYAML controls how much conversation is sent with each LLM call
+type HistoryYAML struct {
+	MaxMessages  *int   `yaml:"max_messages"` // default 50, 0 keeps every message
+	MaxTokens    int    `yaml:"max_tokens"`   // 0 fits the model's context window
+	Summarize    bool   `yaml:"summarize"`
+	SummaryModel string `yaml:"summary_model"` // same provider as llm.model, default llm.model
+}
+
+// BudgetYAML holds the agent's hard limits; 0 means unlimited, except
+// max_tool_iterations which defaults to 25
+type BudgetYAML struct {
+	MaxSessionTokens  int     `yaml:"max_session_tokens"`
+	MaxDailyCostUSD   float64 `yaml:"max_daily_cost_usd"`
+	MaxToolIterations int     `yaml:"max_tool_iterations"`
 }
 
 // LLMConfigYAML represents LLM settings from YAML
@@ -34,6 +67,20 @@ type LLMConfigYAML struct {
 	Model       string  `yaml:"model"`
 	Temperature float32 `yaml:"temperature"`
 	MaxTokens   int     `yaml:"max_tokens"`
+	Provider    string  `yaml:"provider"`        // optional, detected from model when empty
+	BaseURL     string  `yaml:"base_url"`        // optional API root override
+	MaxRetries  *int    `yaml:"max_retries"`     // default 3, 0 disables retries
+	Timeout     int     `yaml:"timeout_seconds"` // per request attempt, default 300
+
+	Fallback []FallbackYAML `yaml:"fallback"` // tried in order when a call fails
+}
+
+// FallbackYAML is one link of the fallback chain. Unset temperature and
+// max_tokens come from the primary model, and so does the API key when both
+// use the same provider.
+type FallbackYAML struct {
+	LLMConfigYAML `yaml:",inline"`
+	On            []string `yaml:"on"` // error classes, default rate_limit, overloaded, server_error
 }
 
 func isBool(val interface{}) bool {
@@ -41,7 +88,24 @@ func isBool(val interface{}) bool {
 	return ok
 }
 
-func ParseAgentConfig() (*agent.Agent, error) {
+// missingKeyPlaceholder stands in for API keys that are not set when the
+// config is parsed by ParseAgentConfigWithoutKeys
+const missingKeyPlaceholder = "unset"
+
+// ParseAgentConfig builds every agent in agentconfig.yaml, along with the
+// router agent when the config has one
+func ParseAgentConfig() (*agent.Roster, error) {
+	return parseAgentConfig(true)
+}
+
+// ParseAgentConfigWithoutKeys parses the config like ParseAgentConfig but
+// does not require API keys to be set, for commands that never call a
+// provider. Missing keys are replaced by a placeholder.
+func ParseAgentConfigWithoutKeys() (*agent.Roster, error) {
+	return parseAgentConfig(false)
+}
+
+func parseAgentConfig(requireKeys bool) (*agent.Roster, error) {
 	// Read agent.yaml from project root
 	data, err := os.ReadFile("./agentconfig.yaml")
 	if err != nil {
@@ -54,25 +118,119 @@ func ParseAgentConfig() (*agent.Agent, error) {
 		return nil, fmt.Errorf("failed to parse agent.yaml: %w", err)
 	}
 
-	// For now, use the first agent (can extend to support multiple later)
 	if len(config.Agents) == 0 {
 		return nil, fmt.Errorf("no agents defined in config")
 	}
 
-	agentDef := config.Agents[0]
+	// Local catalog entries add models or correct limits and prices
+	if err := modelcatalog.LoadOverride(modelcatalog.DefaultOverridePath); err != nil {
+		return nil, err
+	}
+
+	roster := &agent.Roster{}
+	// Agents listing the same server config share one server
+	servers := make(map[string]*server.MCPServer)
 
-	// Resolve API key (check env variable if needed)
-	apiKey := resolveEnvVar(agentDef.LLM.APIKey)
-	if apiKey == "" {
-		return nil, fmt.Errorf("API key not found for agent %s", agentDef.AgentID)
+	for _, agentDef := range config.Agents {
+		if _, err := roster.Get(agentDef.AgentID); err == nil {
+			return nil, fmt.Errorf("agent %s is defined more than once", agentDef.AgentID)
+		}
+
+		ag, err := buildAgent(agentDef, servers, requireKeys)
+		if err != nil {
+			return nil, err
+		}
+		roster.Agents = append(roster.Agents, ag)
 	}
 
-	// Create LLMConfig for Agent constructor
-	LLMConfig := &agent.LLMConfig{
-		APIKey:      apiKey,
-		Model:       agentDef.LLM.Model,
-		Temperature: agentDef.LLM.Temperature,
-		MaxTokens:   agentDef.LLM.MaxTokens,
+	defaultIndex := 0
+	if config.DefaultAgent != "" {
+		defaultAgent, err := roster.Get(config.DefaultAgent)
+		if err != nil {
+			return nil, fmt.Errorf("default_agent: %w", err)
+		}
+		defaultIndex = slices.Index(roster.Agents, defaultAgent)
+	}
+	roster.Default = roster.Agents[defaultIndex]
+
+	if config.Router != nil {
+		router, err := buildRouter(*config.Router, config.Agents[defaultIndex].LLM, roster, requireKeys)
+		if err != nil {
+			return nil, err
+		}
+		roster.Router = router
+	}
+
+	return roster, nil
+}
+
+// buildRouter creates the router agent. It only ever makes routing calls,
+// so it has no servers.
+func buildRouter(routerDef RouterYAML, defaultLLM LLMConfigYAML, roster *agent.Roster, requireKeys bool) (*agent.Agent, error) {
+	if len(roster.Agents) < 2 {
+		return nil, fmt.Errorf("router needs at least two agents to choose from")
+	}
+	for _, ag := range roster.Agents {
+		if ag.AgentID == agent.RouterAgentID {
+			return nil, fmt.Errorf("agent ID %q is reserved for the router; rename that agent", agent.RouterAgentID)
+		}
+	}
+
+	LLMConfig := roster.Default.LLMConfig
+	if routerDef.LLM.Model != "" {
+		var err error
+		routerLLM := inheritLLM(routerDef.LLM, defaultLLM)
+		LLMConfig, err = buildLLMConfig(routerLLM, agent.RouterAgentID, requireKeys)
+		if err != nil {
+			return nil, fmt.Errorf("router: %w", err)
+		}
+		if err := addFallbacks(LLMConfig, routerLLM, agent.RouterAgentID, requireKeys); err != nil {
+			return nil, fmt.Errorf("router: %w", err)
+		}
+	} else if len(routerDef.LLM.Fallback) > 0 {
+		return nil, fmt.Errorf("router: llm.fallback needs llm.model; without one the router uses the default agent's model and fallbacks")
+	}
+
+	return agent.NewAgent(
+		agent.RouterAgentID,
+		"Routes each user message to the agent best suited to it",
+		registry.NewRegistry(),
+		LLMConfig,
+		false,
+		false,
+		false,
+	), nil
+}
+
+// addFallbacks builds llm's fallback chain onto LLMConfig. Each fallback
+// inherits the settings it leaves out from llm.
+func addFallbacks(LLMConfig *agent.LLMConfig, llm LLMConfigYAML, agentID string, requireKeys bool) error {
+	for _, fallback := range llm.Fallback {
+		fallbackYAML := inheritLLM(fallback.LLMConfigYAML, llm)
+
+		fallbackConfig, err := buildLLMConfig(fallbackYAML, agentID, requireKeys)
+		if err != nil {
+			return fmt.Errorf("fallback %s: %w", fallbackYAML.Model, err)
+		}
+
+		on := fallback.On
+		if len(on) == 0 {
+			on = agent.DefaultFallbackOn
+		}
+		LLMConfig.Fallbacks = append(LLMConfig.Fallbacks, agent.FallbackConfig{LLM: *fallbackConfig, On: on})
+	}
+	return nil
+}
+
+// buildAgent creates one agent and its registry. servers caches parsed
+// server configs by path.
+func buildAgent(agentDef AgentDefinition, servers map[string]*server.MCPServer, requireKeys bool) (*agent.Agent, error) {
+	LLMConfig, err := buildLLMConfig(agentDef.LLM, agentDef.AgentID, requireKeys)
+	if err != nil {
+		return nil, err
+	}
+	if err := addFallbacks(LLMConfig, agentDef.LLM, agentDef.AgentID, requireKeys); err != nil {
+		return nil, err
 	}
 
 	// Create registry
@@ -80,33 +238,59 @@ func ParseAgentConfig() (*agent.Agent, error) {
 
 	// Parse and register each server
 	for _, serverPath := range agentDef.Servers {
-		server, runtimeconfig, err := parseserverprotocol.ParseServerConfig(serverPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse server %s: %w", serverPath, err)
+		srv, parsed := servers[serverPath]
+		if !parsed {
+			var runtimeconfig *server.RuntimeConfig
+			var err error
+			srv, runtimeconfig, err = parseserverprotocol.ParseServerConfig(serverPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse server %s: %w", serverPath, err)
+			}
+			// Store runtime config on the server for later use when executing
+			srv.RuntimeConfig = runtimeconfig
+			servers[serverPath] = srv
 		}
-		// Store runtime config on the server for later use when executing
-		server.RuntimeConfig = runtimeconfig
-		err = reg.AddServer(server)
-		if err != nil {
-			return nil, fmt.Errorf("failed to register server %s: %w", server.ServerID, err)
+		if err := reg.AddServer(srv); err != nil {
+			return nil, fmt.Errorf("failed to register server %s: %w", srv.ServerID, err)
 		}
 	}
 
 	if !agentDef.ServerGeneration || !isBool(agentDef.ServerGeneration) {
-		fmt.Printf("Server generation disabled or invalid for agent %s, defaulting to false\n", agentDef.AgentID)
+		log.Printf("Server generation disabled or invalid for agent %s, defaulting to false", agentDef.AgentID)
 		agentDef.ServerGeneration = false
 	}
 
 	if !agentDef.VoiceChat || !isBool(agentDef.VoiceChat) {
-		fmt.Printf("Voice chat disabled or invalid for agent %s, defaulting to false\n", agentDef.AgentID)
+		log.Printf("Voice chat disabled or invalid for agent %s, defaulting to false", agentDef.AgentID)
 		agentDef.VoiceChat = false
 	}
 
 	if !agentDef.InfraGeneration || !isBool(agentDef.InfraGeneration) {
-		fmt.Printf("Infrastructure generation disabled or invalid for agent %s, defaulting to false\n", agentDef.AgentID)
+		log.Printf("Infrastructure generation disabled or invalid for agent %s, defaulting to false", agentDef.AgentID)
 		agentDef.InfraGeneration = false
 	}
 
+	if agentDef.MaxParallelTools < 0 {
+		return nil, fmt.Errorf("max_parallel_tools must be positive for agent %s", agentDef.AgentID)
+	}
+
+	if agentDef.ToolTimeout < 0 {
+		return nil, fmt.Errorf("tool_timeout_seconds must be positive for agent %s", agentDef.AgentID)
+	}
+
+	budget := agentDef.Budget
+	if budget.MaxSessionTokens < 0 || budget.MaxDailyCostUSD < 0 || budget.MaxToolIterations < 0 {
+		return nil, fmt.Errorf("budget limits must be positive for agent %s", agentDef.AgentID)
+	}
+
+	history := agentDef.History
+	if (history.MaxMessages != nil && *history.MaxMessages < 0) || history.MaxTokens < 0 {
+		return nil, fmt.Errorf("history limits must be positive for agent %s", agentDef.AgentID)
+	}
+	if err := validateSummaryModel(history.SummaryModel, agentDef.LLM); err != nil {
+		return nil, fmt.Errorf("history.summary_model for agent %s: %w", agentDef.AgentID, err)
+	}
+
 	// Create agent using your NewAgent constructor
 	ag := agent.NewAgent(
 		agentDef.AgentID,
@@ -118,9 +302,130 @@ func ParseAgentConfig() (*agent.Agent, error) {
 		agentDef.InfraGeneration,
 	)
 
+	if agentDef.MaxParallelTools > 0 {
+		ag.MaxParallelTools = agentDef.MaxParallelTools
+	}
+	if agentDef.ToolTimeout > 0 {
+		ag.ToolTimeout = time.Duration(agentDef.ToolTimeout) * time.Second
+	}
+
+	ag.Budget.MaxSessionTokens = budget.MaxSessionTokens
+	ag.Budget.MaxDailyCostUSD = budget.MaxDailyCostUSD
+	if budget.MaxToolIterations > 0 {
+		ag.Budget.MaxToolIterations = budget.MaxToolIterations
+	}
+
+	if history.MaxMessages != nil {
+		ag.History.MaxMessages = *history.MaxMessages
+	}
+	ag.History.MaxTokens = history.MaxTokens
+	ag.History.Summarize = history.Summarize
+	ag.History.SummaryModel = strings.TrimSpace(history.SummaryModel)
+
 	return ag, nil
 }
 
+// inheritLLM fills the settings llm leaves unset from parent, including the
+// API key and endpoint when both use the same provider
+func inheritLLM(llm, parent LLMConfigYAML) LLMConfigYAML {
+	if llm.Temperature == 0 {
+		llm.Temperature = parent.Temperature
+	}
+	if llm.MaxTokens == 0 {
+		llm.MaxTokens = parent.MaxTokens
+	}
+	if llm.MaxRetries == nil {
+		llm.MaxRetries = parent.MaxRetries
+	}
+	if llm.Timeout == 0 {
+		llm.Timeout = parent.Timeout
+	}
+	if llm.APIKey == "" && llm.BaseURL == "" && providerOf(llm) == providerOf(parent) {
+		llm.APIKey = parent.APIKey
+		llm.BaseURL = parent.BaseURL
+	}
+	return llm
+}
+
+// validateSummaryModel checks the summary model can be called through the
+// agent's own provider, since summaries reuse its API key and endpoint
+func validateSummaryModel(summaryModel string, llm LLMConfigYAML) error {
+	summaryModel = strings.TrimSpace(summaryModel)
+	if summaryModel == "" || providerOf(llm) == agent.ProviderOpenAICompatible {
+		return nil
+	}
+
+	model, ok := modelcatalog.Lookup(summaryModel)
+	if !ok {
+		return fmt.Errorf("unknown model %s", summaryModel)
+	}
+	if model.Provider != providerOf(llm) {
+		return fmt.Errorf("%s is served by %s, not %s like llm.model", summaryModel, model.Provider, providerOf(llm))
+	}
+	if model.MaxOutputTokens > 0 && llm.MaxTokens > model.MaxOutputTokens {
+		return fmt.Errorf("llm.max_tokens %d exceeds the %d output tokens %s supports", llm.MaxTokens, model.MaxOutputTokens, summaryModel)
+	}
+	return nil
+}
+
+// buildLLMConfig converts one llm block, resolving its API key. Without
+// requireKeys a missing key is replaced by a placeholder.
+func buildLLMConfig(llm LLMConfigYAML, agentID string, requireKeys bool) (*agent.LLMConfig, error) {
+	provider := strings.ToLower(strings.TrimSpace(llm.Provider))
+
+	// Resolve API key (check env variable if needed); local OpenAI-compatible
+	// servers may run without one
+	apiKey := resolveEnvVar(llm.APIKey)
+	if apiKey == "" && !requireKeys {
+		apiKey = missingKeyPlaceholder
+	}
+	if apiKey == "" && provider != agent.ProviderOpenAICompatible {
+		return nil, fmt.Errorf("API key not found for agent %s", agentID)
+	}
+
+	if provider == agent.ProviderOpenAICompatible && llm.BaseURL == "" {
+		return nil, fmt.Errorf("llm.base_url is required for provider %s (agent %s)", provider, agentID)
+	}
+
+	if llm.Timeout < 0 {
+		return nil, fmt.Errorf("llm.timeout_seconds must be positive for agent %s", agentID)
+	}
+
+	// LLMConfig uses 0 for the default and a negative count to disable retries
+	maxRetries := 0
+	if llm.MaxRetries != nil {
+		if *llm.MaxRetries < 0 {
+			return nil, fmt.Errorf("llm.max_retries must be positive for agent %s", agentID)
+		}
+		maxRetries = *llm.MaxRetries
+		if maxRetries == 0 {
+			maxRetries = -1
+		}
+	}
+
+	return &agent.LLMConfig{
+		APIKey:      apiKey,
+		Model:       llm.Model,
+		Temperature: llm.Temperature,
+		MaxTokens:   llm.MaxTokens,
+		Provider:    provider,
+		BaseURL:     resolveEnvVar(llm.BaseURL),
+		MaxRetries:  maxRetries,
+		Timeout:     time.Duration(llm.Timeout) * time.Second,
+	}, nil
+}
+
+// providerOf returns the configured provider, or the catalog's provider for the model
+func providerOf(llm LLMConfigYAML) string {
+	if provider := strings.ToLower(strings.TrimSpace(llm.Provider)); provider != "" {
+		return provider
+	}
+	if model, ok := modelcatalog.Lookup(llm.Model); ok {
+		return model.Provider
+	}
+	return ""
+}
+
 // resolveEnvVar resolves environment variables in format ${VAR_NAME}
 func resolveEnvVar(value string) string {
 	// Check if value is an env var reference
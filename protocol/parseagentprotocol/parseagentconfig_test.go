@@ -0,0 +1,27 @@
+package parseagentprotocol
+
+import (
+	"strings"
+	"testing"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/registry"
+)
+
+func TestBuildRouterRejectsAgentNamedRouter(t *testing.T) {
+	newAgent := func(id string) *agent.Agent {
+		return agent.NewAgent(id, "Test agent", registry.NewRegistry(), &agent.LLMConfig{
+			APIKey:      "test",
+			Model:       "gpt-4o",
+			Temperature: 0.5,
+			MaxTokens:   100,
+		}, false, false, false)
+	}
+	coder := newAgent("coder")
+	roster := &agent.Roster{Agents: []*agent.Agent{coder, newAgent(agent.RouterAgentID)}, Default: coder}
+
+	_, err := buildRouter(RouterYAML{}, LLMConfigYAML{}, roster, false)
+	if err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Fatalf("buildRouter = %v, want a reserved agent ID error", err)
+	}
+}
@@ -4,6 +4,7 @@ package parseserverprotocol
 import (
 	"fmt"
 	"os"
+	"strings"
 	"gopkg.in/yaml.v3"
 
 	"github.com/AnthonyL103/GOMCP/server"
@@ -20,10 +21,13 @@ type ServerConfig struct {
 
 // RuntimeConfigYAML for deserializing from YAML
 type RuntimeConfigYAML struct {
-	Type    string   `yaml:"type"`
-	Command string   `yaml:"command"`
-	Args    []string `yaml:"args"`
-	Port    int      `yaml:"port"`
+	Type      string   `yaml:"type"`
+	Command   string   `yaml:"command"`
+	Args      []string `yaml:"args"`
+	Port      int      `yaml:"port"`
+	Protocol  string   `yaml:"protocol"`  // "http" (default) or "mcp"
+	Path      string   `yaml:"path"`      // MCP endpoint path, defaults to /mcp
+	Transport string   `yaml:"transport"` // "http" (default) or "stdio"
 }
 
 // ToolConfig represents a tool in the YAML configuration
@@ -82,8 +86,22 @@ func validateServerConfig(config *ServerConfig) error {
 	if config.Description == "" {
 		return fmt.Errorf("description cannot be empty")
 	}
-	if len(config.Tools) == 0 {
-		return fmt.Errorf("at least one tool must be defined")
+	protocol := strings.ToLower(strings.TrimSpace(config.Runtime.Protocol))
+	transport := strings.ToLower(strings.TrimSpace(config.Runtime.Transport))
+	switch protocol {
+	case "", server.ProtocolHTTP, server.ProtocolMCP:
+	default:
+		return fmt.Errorf("runtime.protocol '%s' is not supported (use http or mcp)", config.Runtime.Protocol)
+	}
+	switch transport {
+	case "", server.TransportHTTP:
+	case server.TransportStdio:
+		// JSON-RPC is the only thing that can be framed over the pipes
+		if protocol == server.ProtocolHTTP {
+			return fmt.Errorf("runtime.transport stdio requires protocol mcp")
+		}
+	default:
+		return fmt.Errorf("runtime.transport '%s' is not supported (use http or stdio)", config.Runtime.Transport)
 	}
 	// Validate runtime
 	if config.Runtime.Type == "" {
@@ -153,11 +171,26 @@ func ParseServerConfig(filePath string) (*server.MCPServer, *server.RuntimeConfi
 	}
 
 	// Build RuntimeConfig from parsed YAML
+	protocol := strings.ToLower(strings.TrimSpace(config.Runtime.Protocol))
+	transport := strings.ToLower(strings.TrimSpace(config.Runtime.Transport))
+	if transport == "" {
+		transport = server.TransportHTTP
+	}
+	if transport == server.TransportStdio {
+		protocol = server.ProtocolMCP
+	}
+	if protocol == "" {
+		protocol = server.ProtocolHTTP
+	}
+
 	runtimeConfig := &server.RuntimeConfig{
-		Type:    config.Runtime.Type,
-		Command: config.Runtime.Command,
-		Args:    config.Runtime.Args,
-		Port:    config.Runtime.Port,
+		Type:      config.Runtime.Type,
+		Command:   config.Runtime.Command,
+		Args:      config.Runtime.Args,
+		Port:      config.Runtime.Port,
+		Protocol:  protocol,
+		Path:      config.Runtime.Path,
+		Transport: transport,
 	}
 
 	// Create server with runtime config
@@ -0,0 +1,207 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AnthonyL103/GOMCP/protocol/mcpprotocol"
+	"github.com/AnthonyL103/GOMCP/server"
+	"github.com/AnthonyL103/GOMCP/tool"
+)
+
+// errNoManifest means an HTTP server does not publish GET /tools
+var errNoManifest = errors.New("server does not publish a /tools manifest")
+
+// ToolManifest is the body returned by GET /tools on HTTP tool servers
+type ToolManifest struct {
+	Tools []ManifestTool `json:"tools"`
+}
+
+// ManifestTool describes one tool in a /tools manifest. Handler defaults to the tool ID.
+type ManifestTool struct {
+	ToolID      string          `json:"tool_id"`
+	Description string          `json:"description"`
+	Handler     string          `json:"handler"`
+	InputSchema tool.JSONSchema `json:"input_schema"`
+}
+
+// DiscoverTools asks every running server for the tools it actually serves
+// (MCP tools/list, or GET /tools for HTTP servers) and makes that the server's
+// tool set. Tools also defined in YAML keep the YAML definition as an override,
+// and any disagreement between the two is logged as a warning.
+func (r *Registry) DiscoverTools(ctx context.Context) error {
+	for _, srv := range r.ListServers() {
+		serverID := srv.ServerID
+		discovered, err := discoverServerTools(ctx, srv)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if srv.ToolCount() == 0 {
+				return fmt.Errorf("no tools defined for server %s and discovery failed: %w", serverID, err)
+			}
+			if errors.Is(err, errNoManifest) {
+				log.Printf("Server '%s' has no tool manifest, using YAML tools", serverID)
+			} else {
+				log.Printf("Warning: tool discovery failed for server '%s', using YAML tools: %v", serverID, err)
+			}
+			continue
+		}
+
+		for _, warning := range mergeDiscoveredTools(srv, discovered) {
+			log.Printf("Warning: server '%s': %s", serverID, warning)
+		}
+
+		if srv.ToolCount() == 0 {
+			return fmt.Errorf("server %s does not provide any tools", serverID)
+		}
+		log.Printf("Server '%s' serves %d tools", serverID, len(discovered))
+	}
+
+	return nil
+}
+
+func discoverServerTools(ctx context.Context, srv *server.MCPServer) ([]*tool.Tool, error) {
+	if srv.RuntimeConfig.IsMCP() {
+		return mcpprotocol.DiscoverTools(ctx, srv)
+	}
+	return fetchToolManifest(ctx, srv.RuntimeConfig)
+}
+
+// fetchToolManifest reads GET /tools from an HTTP tool server
+func fetchToolManifest(ctx context.Context, config *server.RuntimeConfig) ([]*tool.Tool, error) {
+	if config == nil || config.Port == 0 {
+		return nil, errNoManifest
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	url := fmt.Sprintf("http://localhost:%d/tools", config.Port)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, errNoManifest
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("manifest error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var manifest ToolManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		// Accept a bare array as well as {"tools": [...]}
+		if arrErr := json.Unmarshal(body, &manifest.Tools); arrErr != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+	}
+
+	tools := make([]*tool.Tool, 0, len(manifest.Tools))
+	for _, mt := range manifest.Tools {
+		handler := mt.Handler
+		if strings.TrimSpace(handler) == "" {
+			handler = mt.ToolID
+		}
+
+		cleanToolID, cleanDesc, cleanHandler, cleanSchema, err := tool.ValidateToolConfig(mt.ToolID, mt.Description, handler, mt.InputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest tool '%s': %w", mt.ToolID, err)
+		}
+
+		tools = append(tools, &tool.Tool{
+			ToolID:      cleanToolID,
+			Description: cleanDesc,
+			InputSchema: cleanSchema,
+			Handler:     cleanHandler,
+		})
+	}
+
+	return tools, nil
+}
+
+// mergeDiscoveredTools adds served tools missing from YAML to the server and
+// returns a description of every difference between YAML and the server.
+func mergeDiscoveredTools(srv *server.MCPServer, discovered []*tool.Tool) []string {
+	warnings := []string{}
+	served := make(map[string]bool, len(discovered))
+
+	for _, t := range discovered {
+		served[t.ToolID] = true
+
+		override, exists := srv.LookupTool(t.ToolID)
+		if !exists {
+			srv.AddToolToServer(t)
+			continue
+		}
+
+		if override.Handler != t.Handler {
+			warnings = append(warnings, fmt.Sprintf("tool '%s' handler is '%s' in YAML but '%s' on the server", t.ToolID, override.Handler, t.Handler))
+		}
+		for _, diff := range schemaDiff(override.InputSchema, t.InputSchema) {
+			warnings = append(warnings, fmt.Sprintf("tool '%s' %s", t.ToolID, diff))
+		}
+	}
+
+	for _, t := range srv.ListTools() {
+		if !served[t.ToolID] {
+			warnings = append(warnings, fmt.Sprintf("tool '%s' is defined in YAML but not served", t.ToolID))
+		}
+	}
+
+	sort.Strings(warnings)
+	return warnings
+}
+
+// schemaDiff compares property names, types and required fields of two schemas
+func schemaDiff(yamlSchema, servedSchema tool.JSONSchema) []string {
+	diffs := []string{}
+
+	for name, prop := range yamlSchema.Properties {
+		servedProp, exists := servedSchema.Properties[name]
+		if !exists {
+			diffs = append(diffs, fmt.Sprintf("property '%s' is in YAML but not in the served schema", name))
+			continue
+		}
+		if prop.Type != servedProp.Type {
+			diffs = append(diffs, fmt.Sprintf("property '%s' is type '%s' in YAML but '%s' on the server", name, prop.Type, servedProp.Type))
+		}
+	}
+
+	for name := range servedSchema.Properties {
+		if _, exists := yamlSchema.Properties[name]; !exists {
+			diffs = append(diffs, fmt.Sprintf("property '%s' is served but missing from YAML", name))
+		}
+	}
+
+	yamlRequired := strings.Join(sortedCopy(yamlSchema.Required), ",")
+	servedRequired := strings.Join(sortedCopy(servedSchema.Required), ",")
+	if yamlRequired != servedRequired {
+		diffs = append(diffs, fmt.Sprintf("required fields are [%s] in YAML but [%s] on the server", yamlRequired, servedRequired))
+	}
+
+	return diffs
+}
+
+func sortedCopy(values []string) []string {
+	out := append([]string{}, values...)
+	sort.Strings(out)
+	return out
+}
@@ -2,16 +2,22 @@ package registry
 
 import (
 	"fmt"
+	"sort"
+	"sync"
+
 	"github.com/AnthonyL103/GOMCP/server"
 )
 
+// Registry holds the agent's servers. It is safe for concurrent use, since
+// server generation adds and removes servers while tools are being called.
 type Registry struct {
-	Servers map[string]*server.MCPServer
+	mu      sync.RWMutex
+	servers map[string]*server.MCPServer
 }
 
 func NewRegistry() *Registry {
 	return &Registry{
-		Servers: make(map[string]*server.MCPServer),
+		servers: make(map[string]*server.MCPServer),
 	}
 }
 
@@ -21,39 +27,54 @@ func (r *Registry) AddServer(srv *server.MCPServer) error {
 		return fmt.Errorf("server cannot be nil")
 	}
 
-	if _, exists := r.Servers[srv.ServerID]; exists {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.servers[srv.ServerID]; exists {
 		return fmt.Errorf("server with ID '%s' already exists", srv.ServerID)
 	}
 
-	r.Servers[srv.ServerID] = srv
+	r.servers[srv.ServerID] = srv
 	return nil
 }
 
 // RemoveServer removes a server from the registry
 func (r *Registry) RemoveServer(serverID string) error {
-	if _, exists := r.Servers[serverID]; !exists {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.servers[serverID]; !exists {
 		return fmt.Errorf("server with ID '%s' does not exist", serverID)
 	}
 
-	delete(r.Servers, serverID)
+	delete(r.servers, serverID)
 	return nil
 }
 
 // GetServer retrieves a server from the registry
 func (r *Registry) GetServer(serverID string) (*server.MCPServer, error) {
-	if srv, exists := r.Servers[serverID]; exists {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if srv, exists := r.servers[serverID]; exists {
 		return srv, nil
 	}
 
 	return nil, fmt.Errorf("server with ID '%s' does not exist", serverID)
 }
 
-// ListServers returns all servers in the registry
+// ListServers returns a snapshot of the servers in the registry, sorted by ID
 func (r *Registry) ListServers() []*server.MCPServer {
-	servers := make([]*server.MCPServer, 0, len(r.Servers))
-	for _, srv := range r.Servers {
+	r.mu.RLock()
+	servers := make([]*server.MCPServer, 0, len(r.servers))
+	for _, srv := range r.servers {
 		servers = append(servers, srv)
 	}
+	r.mu.RUnlock()
+
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].ServerID < servers[j].ServerID
+	})
 	return servers
 }
 
@@ -67,4 +88,4 @@ func (r *Registry) ExecuteTool(serverID, toolID string, input map[string]interfa
 
 	return srv.ExecuteTool(toolID, input)
 }
-*/
\ No newline at end of file
+*/
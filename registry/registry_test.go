@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/AnthonyL103/GOMCP/server"
+)
+
+// Run with -race: servers are added and removed while others are listed
+func TestRegistryConcurrentAccess(t *testing.T) {
+	reg := NewRegistry()
+
+	const workers = 8
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				id := fmt.Sprintf("server-%d-%d", w, i)
+				if err := reg.AddServer(server.NewMCPServer(id, "test server", nil, nil)); err != nil {
+					t.Errorf("AddServer(%s): %v", id, err)
+					return
+				}
+				if _, err := reg.GetServer(id); err != nil {
+					t.Errorf("GetServer(%s): %v", id, err)
+					return
+				}
+				if i%2 == 0 {
+					if err := reg.RemoveServer(id); err != nil {
+						t.Errorf("RemoveServer(%s): %v", id, err)
+						return
+					}
+				}
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				for _, srv := range reg.ListServers() {
+					_ = srv.ServerID
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	servers := reg.ListServers()
+	if len(servers) != workers*25 {
+		t.Fatalf("ListServers() returned %d servers, want %d", len(servers), workers*25)
+	}
+	for i := 1; i < len(servers); i++ {
+		if servers[i-1].ServerID >= servers[i].ServerID {
+			t.Fatalf("ListServers() not sorted: %s before %s", servers[i-1].ServerID, servers[i].ServerID)
+		}
+	}
+}
+
+func TestAddServerRejectsDuplicate(t *testing.T) {
+	reg := NewRegistry()
+	if err := reg.AddServer(server.NewMCPServer("dup", "test server", nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.AddServer(server.NewMCPServer("dup", "test server", nil, nil)); err == nil {
+		t.Fatal("AddServer accepted a duplicate server ID")
+	}
+}
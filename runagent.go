@@ -2,78 +2,47 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
+	"unicode"
 
 	agent "github.com/AnthonyL103/GOMCP/Agent"
 	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/chatstore"
+	"github.com/AnthonyL103/GOMCP/protocol/mcpprotocol"
 	"github.com/AnthonyL103/GOMCP/protocol/parseagentprotocol"
 	"github.com/AnthonyL103/GOMCP/transport"
 	voicechat "github.com/AnthonyL103/GOMCP/voice"
 )
 
-// findModel determines which provider to use based on model name
-func findModel(model string) string {
-	openAIModels := []string{
-		"gpt-4o", "gpt-4o-mini", "gpt-4-turbo",
-		"o1-preview", "o1-mini",
-	}
-
-	anthropicModels := []string{
-		"claude-opus-4-5-20251101",
-		"claude-sonnet-4-5-20250929",
-		"claude-haiku-4-5-20251001",
-	}
-
-	// Check if it's an OpenAI model
-	for _, m := range openAIModels {
-		if m == model {
-			return "OpenAI"
-		}
-	}
-
-	// Check if it's an Anthropic model
-	for _, m := range anthropicModels {
-		if m == model {
-			return "Anthropic"
-		}
-	}
-
-	// Unknown model
-	return ""
-}
-
-// createProvider creates the appropriate provider based on the model
-func createProvider(ag *agent.Agent) (transport.Provider, error) {
-	llmConfig := ag.LLMConfig
-
-	providerType := findModel(llmConfig.Model)
-
-	switch providerType {
-	case "Anthropic":
-		return transport.NewAnthropicProvider(llmConfig), nil
-	case "OpenAI":
-		return transport.NewOpenAIProvider(llmConfig), nil
-	default:
-		return nil, fmt.Errorf("unsupported model: %s", llmConfig.Model)
-	}
-}
-
-func runagent() {
+// startAgents parses the agent config, launches every agent's tool servers,
+// discovers their tools and creates each agent's LLM provider, keyed by agent
+// ID. Shared by every run mode.
+func startAgents() (*agent.Roster, map[string]transport.Provider) {
 	// Parse agent config
-	ag, err := parseagentprotocol.ParseAgentConfig()
+	roster, err := parseagentprotocol.ParseAgentConfig()
 	if err != nil {
 		log.Fatal("Failed to parse agent config:", err)
 	}
 
+	// Servers shared by several agents are started once
+	servers, err := roster.Servers()
+	if err != nil {
+		log.Fatal("Failed to collect servers:", err)
+	}
+
 	// Start all servers and track their processes
 	log.Println("Starting MCP servers...")
-	processes, err := StartAllServers(ag)
+	processes, err := StartAllServers(servers)
 	if err != nil {
 		log.Fatal("Failed to start servers:", err)
 	}
@@ -85,24 +54,108 @@ func runagent() {
 	time.Sleep(2 * time.Second)
 	log.Println("All servers started!")
 
-	// Create chat session
-	chat := chat.NewChat("session-1", 50)
+	if err := servers.DiscoverTools(context.Background()); err != nil {
+		log.Fatal("Failed to discover server tools:", err)
+	}
 
-	// Create provider based on model
-	provider, err := createProvider(ag)
-	if err != nil {
-		log.Fatal("Failed to create provider:", err)
+	// Create providers based on each agent's model
+	providers := make(map[string]transport.Provider, len(roster.Agents))
+	for _, ag := range roster.Agents {
+		provider, err := transport.NewProvider(ag.LLMConfig)
+		if err != nil {
+			log.Fatalf("Failed to create provider for agent %s: %v", ag.AgentID, err)
+		}
+		providers[ag.AgentID] = provider
+		log.Printf("Agent '%s' uses provider: %s", ag.AgentID, provider.GetProviderName())
+	}
+	if roster.Router != nil {
+		log.Printf("Routing messages between %d agents with %s", len(roster.Agents), roster.Router.LLMConfig.Model)
 	}
 
-	log.Printf("Using provider: %s", provider.GetProviderName())
+	return roster, providers
+}
+
+// runagent runs the interactive CLI. Chats are saved as they happen and
+// --session resumes one by name. With several agents, --agent picks one for
+// the whole session; otherwise the router picks one per message, if
+// configured, or the default agent answers.
+func runagent(args []string) {
+	flags := flag.NewFlagSet("agent", flag.ExitOnError)
+	agentID := flags.String("agent", "", "agent to talk to (default: routed per message, or the default agent)")
+	sessionID := flags.String("session", "", "resume this chat session, or start it under this name (default: a new session)")
+	storeOptions := addStoreFlags(flags)
+	listSessions := flags.Bool("list-sessions", false, "list saved chat sessions and exit")
+	deleteSession := flags.String("delete-session", "", "delete a saved chat session and exit")
+	flags.Parse(args)
+
+	store := storeOptions.open()
+	if store != nil {
+		defer store.Close()
+	}
+
+	if *listSessions || *deleteSession != "" {
+		if store == nil {
+			log.Fatal("--list-sessions and --delete-session need a chat store")
+		}
+		manageSessions(store, *listSessions, *deleteSession)
+		return
+	}
+
+	if *sessionID == "" {
+		*sessionID = "session-" + time.Now().Format("20060102-150405")
+	}
+	if err := chatstore.ValidateChatID(*sessionID); err != nil {
+		log.Fatal(err)
+	}
+
+	roster, providers := startAgents()
+	if store != nil {
+		if err := roster.SetUsageStore(store); err != nil {
+			log.Fatal("Failed to open usage store:", err)
+		}
+	}
+
+	ag := roster.Default
+	var router *transport.Router
+	if *agentID != "" {
+		var err error
+		ag, err = roster.Get(*agentID)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else if roster.Router != nil {
+		var err error
+		router, err = transport.NewRouter(roster)
+		if err != nil {
+			log.Fatal("Failed to create router:", err)
+		}
+	}
+	if router == nil {
+		log.Printf("Talking to agent '%s'", ag.AgentID)
+	}
+
+	// Create or resume the chat session
+	chat := ag.NewChat(*sessionID)
+	if store != nil {
+		var err error
+		chat, err = ag.OpenChat(store, *sessionID)
+		if err != nil {
+			log.Fatal("Failed to open chat session:", err)
+		}
+		if chat.MessageCount() > 0 {
+			log.Printf("Resumed session %s (%d messages)", *sessionID, chat.MessageCount())
+		} else {
+			log.Printf("Started session %s (resume it with --session %s)", *sessionID, *sessionID)
+		}
+	}
 
 	if ag.VoiceChat {
 		log.Println("Voice chat enabled - initializing voice chat parser")
-		vcParser := voicechat.NewVoiceChatParser(chat, ag, provider)
+		vcParser := voicechat.NewVoiceChatParser(chat, ag, providers[ag.AgentID])
 		go vcParser.Start()
 	}
 	// Interactive loop
-	log.Println("Agent ready! Type your messages (press Enter twice to send, Ctrl+C to exit):")
+	log.Println("Agent ready! Type your messages (press Enter twice to send, /help for commands, Ctrl+C to exit):")
 
 	scanner := bufio.NewScanner(os.Stdin)
 
@@ -139,35 +192,282 @@ func runagent() {
 			break
 		}
 
-		// Send message to agent
-		err := provider.SendRequest(chat, ag, userMessage)
+		if strings.HasPrefix(userMessage, "/") {
+			message, send := runCommand(chat, ag, userMessage)
+			if !send {
+				continue
+			}
+			userMessage = message
+		}
+
+		ctx, done := beginTurn()
+
+		// Let the router pick the agent for this message
+		if router != nil {
+			routed, err := router.Route(ctx, chat, userMessage)
+			if err != nil {
+				done()
+				log.Printf("Error: %v", err)
+				continue
+			}
+			if routed != ag {
+				log.Printf("Handing over to agent '%s'", routed.AgentID)
+			}
+			ag = routed
+		}
+
+		// Send message to agent, printing tokens as they arrive
+		printer := &streamPrinter{}
+		err := providers[ag.AgentID].SendRequestStream(ctx, chat, ag, userMessage, printer.handle)
+		done()
+		printer.finish()
 		if err != nil {
 			log.Printf("Error: %v", err)
 			continue
 		}
+	}
 
-		// Print last assistant message
-		messages := chat.GetMessages()
-		if len(messages) > 0 {
-			lastMsg := messages[len(messages)-1]
-			if lastMsg.Role == "assistant" {
-				fmt.Printf("\nAssistant: %s\n", lastMsg.Content)
-			}
+	log.Println("Goodbye!")
+}
+
+// storeFlags are the chat store options shared by the commands that read or save chats
+type storeFlags struct {
+	backend *string
+	path    *string
+}
+
+func addStoreFlags(flags *flag.FlagSet) storeFlags {
+	return storeFlags{
+		backend: flags.String("store", chatstore.BackendJSON, "where chats are saved: json, sqlite or none"),
+		path:    flags.String("store-path", "", "chat directory for json or database file for sqlite (default ./chats or ./chats.db)"),
+	}
+}
+
+// open opens the chosen store, or returns nil for none
+func (sf storeFlags) open() chatstore.Store {
+	if *sf.backend == "none" {
+		return nil
+	}
+
+	store, err := chatstore.Open(*sf.backend, *sf.path)
+	if err != nil {
+		log.Fatal("Failed to open chat store:", err)
+	}
+	return store
+}
+
+// manageSessions lists and/or deletes saved chat sessions
+func manageSessions(store chatstore.Store, list bool, deleteID string) {
+	if deleteID != "" {
+		if err := store.Delete(deleteID); err != nil {
+			log.Fatal("Failed to delete session:", err)
 		}
+		fmt.Printf("Deleted session %s\n", deleteID)
 	}
 
-	log.Println("Goodbye!")
+	if !list {
+		return
+	}
+
+	infos, err := store.List()
+	if err != nil {
+		log.Fatal("Failed to list sessions:", err)
+	}
+	if len(infos) == 0 {
+		fmt.Println("No saved sessions")
+		return
+	}
+	for _, info := range infos {
+		fmt.Printf("%-32s %4d messages  updated %s\n", info.ChatID, info.MessageCount, info.UpdatedAt.Format("2006-01-02 15:04"))
+	}
 }
 
-// setupGracefulShutdown handles Ctrl+C and kills server processes
+// runCommand handles a slash command. It returns the message to send when
+// the command starts a turn (/edit), or when the input isn't a command at all.
+func runCommand(c *chat.Chat, ag *agent.Agent, input string) (string, bool) {
+	command, args := cutField(input)
+
+	switch command {
+	case "/help":
+		fmt.Println("/usage               tokens and cost so far")
+		fmt.Println("/history             messages on the current branch, with their index")
+		fmt.Println("/branches            the branch tree")
+		fmt.Println("/fork <index>        new branch keeping the messages before index")
+		fmt.Println("/switch <branch>     make another branch current")
+		fmt.Println("/edit <index> <text> replace a user message on a new branch and regenerate")
+	case "/usage":
+		printUsage(c, ag)
+	case "/history":
+		printHistory(c)
+	case "/branches":
+		printBranches(c)
+	case "/fork":
+		index, err := strconv.Atoi(args)
+		if err != nil {
+			fmt.Println("Usage: /fork <index>")
+			break
+		}
+		branch, err := c.Fork(index)
+		if err != nil {
+			fmt.Println("Fork failed:", err)
+			break
+		}
+		fmt.Printf("Now on branch %s with %d messages\n", branch, c.MessageCount())
+	case "/switch":
+		if err := c.SwitchBranch(args); err != nil {
+			fmt.Println("Switch failed:", err)
+			break
+		}
+		fmt.Printf("Now on branch %s with %d messages\n", c.CurrentBranch(), c.MessageCount())
+	case "/edit":
+		indexArg, text := cutField(args)
+		index, err := strconv.Atoi(indexArg)
+		if err != nil || text == "" {
+			fmt.Println("Usage: /edit <index> <new message>")
+			break
+		}
+		branch, err := c.EditUserMessage(index)
+		if err != nil {
+			fmt.Println("Edit failed:", err)
+			break
+		}
+		fmt.Printf("Regenerating on branch %s\n", branch)
+		return text, true
+	default:
+		return input, true
+	}
+	return "", false
+}
+
+// cutField splits off the first whitespace-separated field of s
+func cutField(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// printHistory lists the current branch's messages with the index /fork and /edit take
+func printHistory(c *chat.Chat) {
+	for i, msg := range c.GetMessages() {
+		text := msg.Text()
+		if calls := msg.ToolCalls(); len(calls) > 0 {
+			text = strings.TrimSpace(fmt.Sprintf("%s [%d tool calls]", text, len(calls)))
+		}
+		if results := msg.ToolResults(); len(results) > 0 {
+			text = fmt.Sprintf("[%d tool results]", len(results))
+		}
+		text = strings.ReplaceAll(text, "\n", " ")
+		if len(text) > 80 {
+			text = text[:80] + "..."
+		}
+		fmt.Printf("%3d %-9s %s\n", i, msg.Role, text)
+	}
+}
+
+// printBranches shows the branch tree, marking the current branch
+func printBranches(c *chat.Chat) {
+	for _, branch := range c.ListBranches() {
+		marker := " "
+		if branch.Active {
+			marker = "*"
+		}
+		origin := ""
+		if branch.Parent != "" {
+			origin = fmt.Sprintf(", forked from %s at %d", branch.Parent, branch.ForkIndex)
+		}
+		fmt.Printf("%s %s (%d messages%s)\n", marker, branch.ID, branch.MessageCount, origin)
+	}
+}
+
+// printUsage shows the tokens and cost of this session and of the agent overall
+func printUsage(c *chat.Chat, ag *agent.Agent) {
+	fmt.Printf("Session: %s\n", c.GetTotalUsage())
+	fmt.Printf("Agent:   %s\n", ag.Usage())
+}
+
+// activeTurn holds the cancel func of the request in flight so Ctrl+C can
+// stop it instead of exiting
+var activeTurn struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// beginTurn returns the context for one request and a func to call when it ends
+func beginTurn() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	activeTurn.mu.Lock()
+	activeTurn.cancel = cancel
+	activeTurn.mu.Unlock()
+
+	return ctx, func() {
+		activeTurn.mu.Lock()
+		activeTurn.cancel = nil
+		activeTurn.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancelActiveTurn cancels the request in flight, reporting whether there was one
+func cancelActiveTurn() bool {
+	activeTurn.mu.Lock()
+	defer activeTurn.mu.Unlock()
+
+	if activeTurn.cancel == nil {
+		return false
+	}
+	activeTurn.cancel()
+	activeTurn.cancel = nil
+	return true
+}
+
+// streamPrinter writes streamed assistant text to stdout, starting a new
+// "Assistant:" line after each tool call
+type streamPrinter struct {
+	inLine bool
+}
+
+func (sp *streamPrinter) handle(event transport.StreamEvent) {
+	switch event.Type {
+	case transport.StreamText:
+		if !sp.inLine {
+			fmt.Print("\nAssistant: ")
+			sp.inLine = true
+		}
+		fmt.Print(event.Text)
+	case transport.StreamToolInput:
+		sp.finish()
+	}
+}
+
+func (sp *streamPrinter) finish() {
+	if sp.inLine {
+		fmt.Println()
+		sp.inLine = false
+	}
+}
+
+// setupGracefulShutdown handles Ctrl+C and kills server processes. While a
+// request is running, Ctrl+C cancels it instead; a second one exits.
 func setupGracefulShutdown(processes []*os.Process) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		<-sigChan
+		for sig := range sigChan {
+			if sig == os.Interrupt && cancelActiveTurn() {
+				log.Println("\nCancelled current request (Ctrl+C again to exit)")
+				continue
+			}
+			break
+		}
 		log.Println("\nReceived shutdown signal, cleaning up...")
 
+		mcpprotocol.DisconnectAll()
+
 		// Kill all server processes
 		for _, proc := range processes {
 			if proc != nil {
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/chatstore"
+	"github.com/AnthonyL103/GOMCP/protocol/parseagentprotocol"
+	"github.com/AnthonyL103/GOMCP/transport"
+)
+
+// Export formats besides the provider names, which export request payloads
+const (
+	exportJSONL    = "jsonl"
+	exportMarkdown = "markdown"
+)
+
+// runexport writes a saved chat session as JSONL, Markdown or the request
+// payload a provider would be sent for it
+func runexport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	sessionID := flags.String("session", "", "chat session to export (required)")
+	format := flags.String("format", exportJSONL, "jsonl, markdown, or a provider (anthropic, openai, gemini) for its request payload")
+	out := flags.String("out", "", "file to write (default stdout)")
+	agentID := flags.String("agent", "", "agent whose system prompt and tools go in a provider payload (default: the default agent)")
+	storeOptions := addStoreFlags(flags)
+	flags.Parse(args)
+
+	if *sessionID == "" {
+		log.Fatal("--session is required")
+	}
+
+	store := openStore(storeOptions)
+	defer store.Close()
+
+	c, err := store.Load(*sessionID)
+	if err != nil {
+		log.Fatal("Failed to load session:", err)
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatal("Failed to create output file:", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case exportJSONL:
+		err = c.WriteJSONL(w)
+	case exportMarkdown:
+		err = c.WriteMarkdown(w)
+	default:
+		err = writePayload(w, *format, *agentID, c)
+	}
+	if err != nil {
+		log.Fatal("Export failed:", err)
+	}
+}
+
+// writePayload writes the provider request body for the chat's next call.
+// The agent config supplies the system prompt and tools; its servers are
+// not started and no API key is needed.
+func writePayload(w io.Writer, provider, agentID string, c *chat.Chat) error {
+	roster, err := parseagentprotocol.ParseAgentConfigWithoutKeys()
+	if err != nil {
+		return err
+	}
+
+	ag := roster.Default
+	if agentID != "" {
+		if ag, err = roster.Get(agentID); err != nil {
+			return err
+		}
+	}
+
+	payload, err := transport.RequestPayload(provider, c, ag)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(payload)
+}
+
+// runimport saves a JSONL export as a chat session that can be resumed with --session
+func runimport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	in := flags.String("in", "", "JSONL file to import (required)")
+	sessionID := flags.String("session", "", "save under this session name instead of the exported one")
+	storeOptions := addStoreFlags(flags)
+	flags.Parse(args)
+
+	if *in == "" {
+		log.Fatal("--in is required")
+	}
+
+	file, err := os.Open(*in)
+	if err != nil {
+		log.Fatal("Failed to open import file:", err)
+	}
+	defer file.Close()
+
+	c, err := chat.ReadJSONL(file)
+	if err != nil {
+		log.Fatal("Failed to read import file:", err)
+	}
+	if *sessionID != "" {
+		c.ChatID = *sessionID
+	}
+	if err := chatstore.ValidateChatID(c.ChatID); err != nil {
+		log.Fatal(err)
+	}
+
+	store := openStore(storeOptions)
+	defer store.Close()
+
+	_, err = store.Load(c.ChatID)
+	if err == nil {
+		log.Fatalf("Session %s already exists; pick another name with --session", c.ChatID)
+	}
+	if !errors.Is(err, chat.ErrChatNotFound) {
+		log.Fatal("Failed to check for an existing session:", err)
+	}
+	if err := store.Save(c); err != nil {
+		log.Fatal("Failed to save session:", err)
+	}
+	log.Printf("Imported %d messages as session %s", c.MessageCount(), c.ChatID)
+}
+
+// openStore opens the store for commands that can't run without one
+func openStore(storeOptions storeFlags) chatstore.Store {
+	store := storeOptions.open()
+	if store == nil {
+		log.Fatal("this command needs a chat store")
+	}
+	return store
+}
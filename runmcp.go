@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/AnthonyL103/GOMCP/gateway"
+)
+
+// runmcp serves the agent as an MCP server, over stdio by default or over
+// streamable HTTP when --http is given.
+func runmcp(args []string) {
+	flags := flag.NewFlagSet("mcp", flag.ExitOnError)
+	httpAddr := flags.String("http", "", "serve MCP over streamable HTTP on this address (e.g. localhost:8090) instead of stdio")
+	httpPath := flags.String("path", "/mcp", "HTTP endpoint path")
+	allowOrigins := flags.String("allow-origin", "", "comma-separated browser origins allowed to call the HTTP endpoint")
+	agentID := flags.String("agent", "", "agent to serve (default: the default agent)")
+	storeOptions := addStoreFlags(flags)
+	flags.Parse(args)
+
+	// In stdio mode stdout carries the protocol, so everything else that
+	// prints (config parsing, launched servers, tool logs) goes to stderr.
+	protocolOut := os.Stdout
+	if *httpAddr == "" {
+		os.Stdout = os.Stderr
+	}
+
+	// Chat tool sessions live in memory; the store keeps the agents' daily usage
+	store := storeOptions.open()
+	if store != nil {
+		defer store.Close()
+	}
+
+	roster, providers := startAgents()
+	if store != nil {
+		if err := roster.SetUsageStore(store); err != nil {
+			log.Fatal("Failed to open usage store:", err)
+		}
+	}
+	ag := roster.Default
+	if *agentID != "" {
+		var err error
+		ag, err = roster.Get(*agentID)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	mcpServer := gateway.NewServer(ag, providers[ag.AgentID])
+	for _, origin := range strings.Split(*allowOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			mcpServer.AllowOrigins = append(mcpServer.AllowOrigins, origin)
+		}
+	}
+
+	if *httpAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle(*httpPath, mcpServer)
+		log.Printf("Serving agent '%s' over MCP on http://%s%s", ag.AgentID, *httpAddr, *httpPath)
+		log.Fatal(http.ListenAndServe(*httpAddr, mux))
+	}
+
+	log.Printf("Serving agent '%s' over MCP on stdio", ag.AgentID)
+	if err := mcpServer.ServeStdio(context.Background(), os.Stdin, protocolOut); err != nil {
+		log.Fatal("MCP stdio error:", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/AnthonyL103/GOMCP/api"
+)
+
+// runserve starts the HTTP API used by the web client
+func runserve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on; use :8080 to accept remote connections")
+	allowOrigin := flags.String("cors-origin", "", "origin allowed to call the API from another site (empty allows same-origin only)")
+	storeOptions := addStoreFlags(flags)
+	flags.Parse(args)
+
+	// API sessions live in memory; the store keeps the agents' daily usage
+	store := storeOptions.open()
+	if store != nil {
+		defer store.Close()
+	}
+
+	roster, _ := startAgents()
+	if store != nil {
+		if err := roster.SetUsageStore(store); err != nil {
+			log.Fatal("Failed to open usage store:", err)
+		}
+	}
+	apiServer, err := api.NewServer(roster, *allowOrigin)
+	if err != nil {
+		log.Fatal("Failed to create API server:", err)
+	}
+
+	log.Printf("Serving agents %s API on %s", strings.Join(roster.AgentIDs(), ", "), *addr)
+	log.Fatal(http.ListenAndServe(*addr, apiServer.Handler()))
+}
@@ -2,7 +2,9 @@ package server
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/AnthonyL103/GOMCP/tool"
 )
@@ -12,18 +14,48 @@ func isString(val interface{}) bool {
 	return ok
 }
 
+// MCPServer is a tool server known to the agent. Its tool set is guarded by
+// a mutex since discovery and server generation change it while tools run.
 type MCPServer struct {
 	ServerID string
 	Description string
-	Tools map[string]*tool.Tool
 	RuntimeConfig *RuntimeConfig
+
+	mu    sync.RWMutex
+	tools map[string]*tool.Tool
 }
 
+// Wire protocols a tool server can speak
+const (
+	ProtocolHTTP = "http" // legacy POST /execute/{handler}
+	ProtocolMCP  = "mcp"  // MCP JSON-RPC 2.0 (initialize, tools/list, tools/call)
+)
+
+// Channels a tool server can be reached over
+const (
+	TransportHTTP  = "http"  // localhost:<port>
+	TransportStdio = "stdio" // the launched process's stdin/stdout
+)
+
 type RuntimeConfig struct {
-	Type    string
-	Command string
-	Args    []string
-	Port    int
+	Type      string
+	Command   string
+	Args      []string
+	Port      int
+	Protocol  string
+	Path      string // MCP endpoint path for HTTP servers, defaults to /mcp
+	Transport string
+}
+
+// IsMCP reports whether the server speaks MCP JSON-RPC instead of the /execute routes.
+// Stdio servers always speak JSON-RPC.
+func (c *RuntimeConfig) IsMCP() bool {
+	return c != nil && (strings.ToLower(strings.TrimSpace(c.Protocol)) == ProtocolMCP || c.IsStdio())
+}
+
+// IsStdio reports whether the server is reached over its stdin/stdout pipes
+func (c *RuntimeConfig) IsStdio() bool {
+	return c != nil && strings.ToLower(strings.TrimSpace(c.Transport)) == TransportStdio
 }
 
 func NewMCPServer(
@@ -43,10 +75,6 @@ func NewMCPServer(
 		panic("Description is required and must be a non-empty string")
 	}
 
-	if len(tools) == 0 {
-		panic("At least one tool must be provided")
-	}
-
 	
 	//ensure that no tools are nil as a safeguard and create tool map
 	toolMap := make(map[string]*tool.Tool)
@@ -60,8 +88,8 @@ func NewMCPServer(
 	return &MCPServer{
 		ServerID:    serverID,
 		Description: description,
-		Tools:       toolMap,
 		RuntimeConfig: runtimeconfig,
+		tools:       toolMap,
 	}
 }
 
@@ -74,11 +102,13 @@ func (s *MCPServer) AddToolToServer(
 	if t == nil {
 		panic("Tool cannot be nil")
 	}
-	if _, exists := s.Tools[t.ToolID]; exists {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[t.ToolID]; exists {
 		panic(fmt.Sprintf("Tool with id '%s' already exists in the server", t.ToolID))
 	}
 
-	s.Tools[t.ToolID] = t
+	s.tools[t.ToolID] = t
 	return s
 }
 
@@ -92,10 +122,12 @@ func (s *MCPServer) RemoveToolFromServer(
 	if toolName == "" {
 		panic("Tool name cannot be empty")
 	}
-	if _, exists := s.Tools[toolName]; !exists {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[toolName]; !exists {
 		panic(fmt.Sprintf("Tool with name '%s' does not exist in the server", toolName))
 	}
-	delete(s.Tools, toolName)
+	delete(s.tools, toolName)
 	return s
 }
 
@@ -110,8 +142,38 @@ func (s *MCPServer) GetToolFromServer(
 		panic("Tool name cannot be empty")
 	}
 
-	if t, exists := s.Tools[toolName]; exists {
+	if t, exists := s.LookupTool(toolName); exists {
 		return t
 	}
 	panic(fmt.Sprintf("Tool with name '%s' does not exist in the server", toolName))
+}
+
+// LookupTool returns the tool with the given ID, if the server has it
+func (s *MCPServer) LookupTool(toolID string) (*tool.Tool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, exists := s.tools[toolID]
+	return t, exists
+}
+
+// ListTools returns a snapshot of the server's tools, sorted by ID
+func (s *MCPServer) ListTools() []*tool.Tool {
+	s.mu.RLock()
+	tools := make([]*tool.Tool, 0, len(s.tools))
+	for _, t := range s.tools {
+		tools = append(tools, t)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].ToolID < tools[j].ToolID
+	})
+	return tools
+}
+
+// ToolCount returns how many tools the server has
+func (s *MCPServer) ToolCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tools)
 }
\ No newline at end of file
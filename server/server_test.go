@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/AnthonyL103/GOMCP/tool"
+)
+
+func newTestTool(id string) *tool.Tool {
+	return tool.NewTool(id, "test tool", tool.JSONSchema{}, "handler")
+}
+
+// Run with -race: tools are added and removed while others are looked up
+func TestServerConcurrentToolAccess(t *testing.T) {
+	srv := NewMCPServer("test", "test server", []*tool.Tool{newTestTool("base")}, nil)
+
+	const workers = 8
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				id := fmt.Sprintf("tool-%d-%d", w, i)
+				srv.AddToolToServer(newTestTool(id))
+				if i%2 == 0 {
+					srv.RemoveToolFromServer(id)
+				}
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, ok := srv.LookupTool("base"); !ok {
+					t.Error("LookupTool lost the base tool")
+					return
+				}
+				_ = srv.GetToolFromServer("base")
+				for _, tl := range srv.ListTools() {
+					_ = tl.ToolID
+				}
+				_ = srv.ToolCount()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := srv.ToolCount(), 1+workers*25; got != want {
+		t.Fatalf("ToolCount() = %d, want %d", got, want)
+	}
+	if got := len(srv.ListTools()); got != srv.ToolCount() {
+		t.Fatalf("ListTools() returned %d tools, ToolCount() is %d", got, srv.ToolCount())
+	}
+}
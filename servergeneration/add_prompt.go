@@ -111,32 +111,3 @@ func IsServerGenerationTool(name string) bool {
 	}
 	return false
 }
-
-func OpenAIToolSpecs() []map[string]interface{} {
-	defs := SharedServerGenerationToolDefinitions()
-	tools := make([]map[string]interface{}, 0, len(defs))
-	for _, def := range defs {
-		tools = append(tools, map[string]interface{}{
-			"type": "function",
-			"function": map[string]interface{}{
-				"name":        def.Name,
-				"description": def.Description,
-				"parameters":  def.InputSchema,
-			},
-		})
-	}
-	return tools
-}
-
-func AnthropicToolSpecs() []map[string]interface{} {
-	defs := SharedServerGenerationToolDefinitions()
-	tools := make([]map[string]interface{}, 0, len(defs))
-	for _, def := range defs {
-		tools = append(tools, map[string]interface{}{
-			"name":         def.Name,
-			"description":  def.Description,
-			"input_schema": def.InputSchema,
-		})
-	}
-	return tools
-}
\ No newline at end of file
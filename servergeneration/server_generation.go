@@ -2,6 +2,7 @@ package servergeneration
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -100,7 +101,7 @@ var manager = &ServerManager{
 }
 
 // GenerateServerCodeTool creates server code and validates syntax.
-func GenerateServerCodeTool(ag *agent.Agent, params map[string]interface{}) (string, bool) {
+func GenerateServerCodeTool(ctx context.Context, ag *agent.Agent, params map[string]interface{}) (string, bool) {
 	serverID, serverDescription, tools, imports, err := parseGenerateParams(params)
 	if err != nil {
 		return err.Error(), true
@@ -136,11 +137,14 @@ func GenerateServerCodeTool(ag *agent.Agent, params map[string]interface{}) (str
 	}
 
 	binaryPath := resolveBinaryPath(strings.TrimSuffix(filePath, ".go"))
-	syntax, syntaxErr := ValidateSyntax(filePath, binaryPath)
+	syntax, syntaxErr := ValidateSyntax(ctx, filePath, binaryPath)
 	if syntaxErr != nil {
 		cleanupArtifacts(filePath, binaryPath)
 		manager.releaseGeneration(processID)
 		manager.deallocatePort(port)
+		if ctx.Err() != nil {
+			return fmt.Sprintf("Server generation cancelled: %v", ctx.Err()), true
+		}
 		return fmt.Sprintf("SYNTAX VALIDATION FAILED:\n%s\n\nPlease fix the Go code and try again.", syntax), true
 	}
 
@@ -172,7 +176,7 @@ func GenerateServerCodeTool(ag *agent.Agent, params map[string]interface{}) (str
 }
 
 // DeployAndTestToolsTool starts the server, runs tests, and tears down the test process.
-func DeployAndTestToolsTool(ag *agent.Agent, params map[string]interface{}) (string, bool) {
+func DeployAndTestToolsTool(ctx context.Context, ag *agent.Agent, params map[string]interface{}) (string, bool) {
 	processID, err := getProcessID(params)
 	if err != nil {
 		return err.Error(), true
@@ -193,19 +197,20 @@ func DeployAndTestToolsTool(ag *agent.Agent, params map[string]interface{}) (str
 		return fmt.Sprintf("Missing test_params for tools: %s", strings.Join(missing, ", ")), true
 	}
 
-	cmd, err := startServerProcess(process.BinaryPath)
+	// The test server is killed if ctx is cancelled mid-test
+	cmd, err := startServerProcess(ctx, process.BinaryPath)
 	if err != nil {
 		cleanupProcess(process)
 		return fmt.Sprintf("Failed to start test server: %v", err), true
 	}
 	defer stopProcess(cmd)
 
-	if err := waitForServer(process.Port, 10, 200*time.Millisecond); err != nil {
+	if err := waitForServer(ctx, process.Port, 10, 200*time.Millisecond); err != nil {
 		cleanupProcess(process)
 		return fmt.Sprintf("Server failed to start: %v", err), true
 	}
 
-	results, err := runToolTests(process.Port, process.Tools)
+	results, err := runToolTests(ctx, process.Port, process.Tools)
 	if err != nil {
 		cleanupProcess(process)
 		return fmt.Sprintf("TOOL TEST FAILED:\n%s", results), true
@@ -217,7 +222,7 @@ func DeployAndTestToolsTool(ag *agent.Agent, params map[string]interface{}) (str
 }
 
 // DeployAndRegisterServerTool registers and starts the final server.
-func DeployAndRegisterServerTool(ag *agent.Agent, params map[string]interface{}) (string, bool) {
+func DeployAndRegisterServerTool(ctx context.Context, ag *agent.Agent, params map[string]interface{}) (string, bool) {
 	processID, err := getProcessID(params)
 	if err != nil {
 		return err.Error(), true
@@ -238,11 +243,17 @@ func DeployAndRegisterServerTool(ag *agent.Agent, params map[string]interface{})
 		toolObjs = append(toolObjs, toolObj)
 	}
 
+	if err := ctx.Err(); err != nil {
+		manager.setProcessStage(process.ID, StageTested)
+		return fmt.Sprintf("Deployment cancelled: %v", err), true
+	}
+
 	if err := AddToRegistry(ag.Registry, process.ServerID, process.Description, process.Port, toolObjs); err != nil {
 		return fmt.Sprintf("Failed to register server: %v", err), true
 	}
 
-	cmd, err := startServerProcess(process.BinaryPath)
+	// The deployed server outlives this request, so it is not tied to ctx
+	cmd, err := startServerProcess(context.Background(), process.BinaryPath)
 	if err != nil {
 		return fmt.Sprintf("Failed to start server: %v", err), true
 	}
@@ -283,7 +294,7 @@ func DeployAndRegisterServerTool(ag *agent.Agent, params map[string]interface{})
 }
 
 // CleanupServerGenerationTool removes temporary artifacts for a process.
-func CleanupServerGenerationTool(ag *agent.Agent, params map[string]interface{}) (string, bool) {
+func CleanupServerGenerationTool(ctx context.Context, ag *agent.Agent, params map[string]interface{}) (string, bool) {
 	process, err := getProcessFromParams(params)
 	if err != nil {
 		return err.Error(), true
@@ -310,7 +321,7 @@ func CleanupServerGenerationTool(ag *agent.Agent, params map[string]interface{})
 }
 
 // DeleteServerTool removes a generated server and unregisters it.
-func DeleteServerTool(ag *agent.Agent, params map[string]interface{}) (string, bool) {
+func DeleteServerTool(ctx context.Context, ag *agent.Agent, params map[string]interface{}) (string, bool) {
 	serverID, ok := params["server_id"].(string)
 	if !ok || strings.TrimSpace(serverID) == "" {
 		return "delete_server_tool requires 'server_id' parameter", true
@@ -339,8 +350,8 @@ func DeleteServerTool(ag *agent.Agent, params map[string]interface{}) (string, b
 }
 
 // ValidateSyntax validates Go code by attempting to compile it.
-func ValidateSyntax(sourcePath, binaryPath string) (string, error) {
-	cmd := exec.Command("go", "build", "-o", binaryPath, sourcePath)
+func ValidateSyntax(ctx context.Context, sourcePath, binaryPath string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", binaryPath, sourcePath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return string(output), fmt.Errorf("compilation failed")
@@ -557,34 +568,44 @@ func buildServerSource(serverID string, port int, tools []GeneratedTool, imports
 	return sb.String()
 }
 
-func waitForServer(port int, maxRetries int, delay time.Duration) error {
+func waitForServer(ctx context.Context, port int, maxRetries int, delay time.Duration) error {
 	client := &http.Client{Timeout: 2 * time.Second}
 	url := fmt.Sprintf("http://localhost:%d/execute/", port)
 	var lastErr error
 	for i := 0; i < maxRetries; i++ {
-		resp, err := client.Get(url)
-		if err == nil {
-			body, readErr := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			if readErr != nil {
-				lastErr = readErr
-				time.Sleep(delay)
-				continue
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
 			}
-			if resp.StatusCode == http.StatusOK && strings.TrimSpace(string(body)) == "ok" {
-				return nil
-			}
-			lastErr = fmt.Errorf("unexpected health response: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
-			time.Sleep(delay)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
 			continue
 		}
-		lastErr = err
-		time.Sleep(delay)
+
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			lastErr = readErr
+			continue
+		}
+		if resp.StatusCode == http.StatusOK && strings.TrimSpace(string(body)) == "ok" {
+			return nil
+		}
+		lastErr = fmt.Errorf("unexpected health response: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 	return fmt.Errorf("health check failed: %v", lastErr)
 }
 
-func runToolTests(port int, tools []GeneratedTool) (string, error) {
+func runToolTests(ctx context.Context, port int, tools []GeneratedTool) (string, error) {
 	client := &http.Client{Timeout: 2 * time.Second}
 	var testResults strings.Builder
 	testResults.WriteString("TESTING TOOLS:\n")
@@ -596,7 +617,13 @@ func runToolTests(port int, tools []GeneratedTool) (string, error) {
 			return fmt.Sprintf("Failed to marshal test payload for %s: %v", tool.ToolID, err), err
 		}
 
-		resp, err := client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return fmt.Sprintf("Tool '%s': Failed to create request: %v", tool.ToolID, err), err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
 		if err != nil {
 			return fmt.Sprintf("Tool '%s': Failed to connect: %v", tool.ToolID, err), err
 		}
@@ -640,8 +667,10 @@ func AddToRegistry(reg *registry.Registry, serverID, description string, port in
 	return reg.AddServer(mcpServer)
 }
 
-func startServerProcess(binaryPath string) (*exec.Cmd, error) {
-	cmd := exec.Command(binaryPath)
+// startServerProcess launches a generated server binary. The process is
+// killed if ctx is cancelled before it exits.
+func startServerProcess(ctx context.Context, binaryPath string) (*exec.Cmd, error) {
+	cmd := exec.CommandContext(ctx, binaryPath)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Start(); err != nil {
@@ -8,7 +8,8 @@ import (
 	"os/exec"
 	"strings"
 
-	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/protocol/mcpprotocol"
+	"github.com/AnthonyL103/GOMCP/registry"
 	"github.com/AnthonyL103/GOMCP/server"
 )
 
@@ -54,10 +55,16 @@ func buildcommand(config *server.RuntimeConfig) (string, []string, error) {
 	}
 }
 
-// StartServer launches a server process and returns the process handle
+// StartServer launches a server process and returns the process handle.
+// Stdio servers keep their stdin/stdout pipes attached to an MCP client
+// instead of writing to our terminal.
 func StartServer(srv *server.MCPServer) (*os.Process, error) {
 	config := srv.RuntimeConfig
-	log.Printf("Starting server '%s' on port %d (%s)", srv.ServerID, config.Port, config.Type)
+	if config.IsStdio() {
+		log.Printf("Starting server '%s' over stdio (%s)", srv.ServerID, config.Type)
+	} else {
+		log.Printf("Starting server '%s' on port %d (%s)", srv.ServerID, config.Port, config.Type)
+	}
 
 	exe, args, err := buildcommand(config)
 	if err != nil {
@@ -65,29 +72,62 @@ func StartServer(srv *server.MCPServer) (*os.Process, error) {
 	}
 
 	cmd := exec.Command(exe, args...)
-	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
+	var transport *mcpprotocol.StdioTransport
+	if config.IsStdio() {
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open stdin for server %s: %w", srv.ServerID, err)
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open stdout for server %s: %w", srv.ServerID, err)
+		}
+		transport = mcpprotocol.NewStdioTransport(stdout, stdin)
+	} else {
+		cmd.Stdout = os.Stdout
+	}
+
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start server %s: %w", srv.ServerID, err)
 	}
 
+	if transport != nil {
+		mcpprotocol.Register(mcpprotocol.NewClient(srv.ServerID, transport))
+	}
+	go waitServer(srv.ServerID, cmd, transport)
+
 	log.Printf("Server '%s' started (PID: %d)", srv.ServerID, cmd.Process.Pid)
 	return cmd.Process, nil
 }
 
+// waitServer reaps the server process when it exits, so it doesn't linger as
+// a zombie, and logs how it exited. For stdio servers it waits for the
+// transport to finish reading stdout first, since Wait closes the pipe.
+func waitServer(serverID string, cmd *exec.Cmd, transport *mcpprotocol.StdioTransport) {
+	if transport != nil {
+		<-transport.Done()
+	}
+	if err := cmd.Wait(); err != nil {
+		log.Printf("Server '%s' exited: %v", serverID, err)
+		return
+	}
+	log.Printf("Server '%s' exited", serverID)
+}
+
 // StartAllServers launches all servers and returns their process handles
-func StartAllServers(ag *agent.Agent) ([]*os.Process, error) {
+func StartAllServers(servers *registry.Registry) ([]*os.Process, error) {
 	processes := []*os.Process{}
 
-	for serverID, srv := range ag.Registry.Servers {
+	for _, srv := range servers.ListServers() {
 		proc, err := StartServer(srv)
 		if err != nil {
 			// Kill already started processes on error
 			for _, p := range processes {
 				p.Kill()
 			}
-			return nil, fmt.Errorf("failed to start server %s: %w", serverID, err)
+			return nil, fmt.Errorf("failed to start server %s: %w", srv.ServerID, err)
 		}
 		processes = append(processes, proc)
 	}
@@ -59,6 +59,7 @@ func ValidateToolConfig(toolID, description, handler string, inputSchema JSONSch
 	validTypes := map[string]bool{
 		"string":  true,
 		"number":  true,
+		"integer": true,
 		"boolean": true,
 		"array":   true,
 		"object":  true,
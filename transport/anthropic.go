@@ -2,17 +2,17 @@
 package transport
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	agent "github.com/AnthonyL103/GOMCP/Agent"
 	"github.com/AnthonyL103/GOMCP/chat"
-	"github.com/AnthonyL103/GOMCP/infrageneration"
 	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
-	"github.com/AnthonyL103/GOMCP/servergeneration"
 )
 
 type AnthropicProvider struct {
@@ -20,295 +20,262 @@ type AnthropicProvider struct {
 	Model       string
 	Temperature float32
 	MaxTokens   int
-	// Optional callback fired immediately after each tool cycle completes,
-	// before the next LLM call. Used by the HTTP server to broadcast over WS.
-	OnToolCall func(msg chat.Message)
+	// MaxRetries and Timeout tune the HTTP layer; zero uses the agent defaults
+	MaxRetries int
+	Timeout    time.Duration
+	// BaseURL is the API root, without the /messages path
+	BaseURL string
+	// Optional tool progress callbacks. Used by the HTTP server to broadcast over WS.
+	llmprotocol.Hooks
 }
 
+const anthropicBaseURL = "https://api.anthropic.com/v1"
+
 func NewAnthropicProvider(config *agent.LLMConfig) *AnthropicProvider {
 	return &AnthropicProvider{
 		APIKey:      config.APIKey,
 		Model:       config.Model,
 		Temperature: config.Temperature,
 		MaxTokens:   config.MaxTokens,
+		MaxRetries:  config.MaxRetries,
+		Timeout:     config.Timeout,
+		BaseURL:     baseURL(config, anthropicBaseURL),
 	}
 }
 
-func (p *AnthropicProvider) GetProviderName() string {
-	return "anthropic"
+// SetToolCallbacks installs the tool progress callbacks
+func (p *AnthropicProvider) SetToolCallbacks(onStart func(call chat.ToolCall), onResult func(call chat.ToolCall, result chat.ToolResult)) {
+	p.OnToolStart = onStart
+	p.OnToolResult = onResult
 }
 
-func (p *AnthropicProvider) SendRequest(c *chat.Chat, ag *agent.Agent, userMessage string) error {
-	c.AddUserMessage(userMessage)
+func (p *AnthropicProvider) GetProviderName() string {
+	return agent.ProviderAnthropic
+}
 
-	agentInstructions := llmprotocol.GetAgentInstructions(ag)
-	availableTools := llmprotocol.ExtractTools(ag)
-	formattedTools := p.buildTools(availableTools, ag)
-	messages := p.buildMessages(c)
+func (p *AnthropicProvider) SendRequest(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string) error {
+	return llmprotocol.RunTurn(ctx, p, c, ag, userMessage, p.Hooks, nil)
+}
 
-	requestBody := map[string]interface{}{
-		"model":       p.Model,
-		"max_tokens":  p.MaxTokens,
-		"temperature": p.Temperature,
-		"system":      agentInstructions,
-		"messages":    messages,
-	}
+func (p *AnthropicProvider) SendRequestStream(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string, onEvent StreamHandler) error {
+	return llmprotocol.RunTurn(ctx, p, c, ag, userMessage, p.Hooks, onEvent)
+}
 
-	if len(formattedTools) > 0 {
-		requestBody["tools"] = formattedTools
-	}
+// Complete sends one Messages API request; a nil req.OnEvent uses the
+// non-streaming API
+func (p *AnthropicProvider) Complete(ctx context.Context, req *llmprotocol.Request) (*llmprotocol.Response, error) {
+	model := req.ModelOr(p.Model)
+	requestBody := p.BuildRequestBody(req)
 
-	response, err := p.sendHTTPRequest(requestBody)
+	response, err := p.sendHTTPRequest(ctx, requestBody, req.OnEvent)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	responseText, toolCallID, toolName, toolParams, stopReason, err := p.parseResponse(response)
+	blocks, stopReason, err := p.parseResponse(response)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	toolsProcessed := false
-	for stopReason == "tool_use" && toolName != "" {
-		toolsProcessed = true
+	// The neutral stop reasons use Anthropic's names
+	return &llmprotocol.Response{Blocks: blocks, StopReason: stopReason, Usage: p.parseUsage(response, model)}, nil
+}
+
+// BuildRequestBody encodes req as a Messages API request body
+func (p *AnthropicProvider) BuildRequestBody(req *llmprotocol.Request) map[string]interface{} {
+	requestBody := map[string]interface{}{
+		"model":       req.ModelOr(p.Model),
+		"max_tokens":  p.MaxTokens,
+		"temperature": p.Temperature,
+		"system":      req.System,
+		"messages":    p.buildMessages(req.Messages),
+	}
 
-		currentToolCallID := toolCallID
-		currentToolName := toolName
-		currentToolParams := toolParams
+	if len(req.Tools) > 0 {
+		requestBody["tools"] = p.buildTools(req.Tools)
+	}
+	return requestBody
+}
 
-		toolInfo, exists := availableTools[currentToolName]
-		if !exists {
-			if !isServerGenerationToolAnthropic(currentToolName) && !isInfraGenerationToolAnthropic(currentToolName) {
-				return fmt.Errorf("tool %s not found", currentToolName)
-			}
-			toolInfo = llmprotocol.ToolInfo{ServerID: "infrastructure_generation", Handler: currentToolName}
-		}
+// parseUsage reads the usage block; input_tokens already excludes cached input
+func (p *AnthropicProvider) parseUsage(response map[string]interface{}, model string) *chat.Usage {
+	usage, _ := response["usage"].(map[string]interface{})
+	return &chat.Usage{
+		Model:            model,
+		InputTokens:      intField(usage, "input_tokens"),
+		OutputTokens:     intField(usage, "output_tokens"),
+		CacheReadTokens:  intField(usage, "cache_read_input_tokens"),
+		CacheWriteTokens: intField(usage, "cache_creation_input_tokens"),
+	}
+}
 
-		if isServerGenerationToolAnthropic(currentToolName) && !ag.ServerGeneration {
-			return fmt.Errorf("tool %s not available; enable server generation in config", currentToolName)
-		}
+// buildMessages converts the chat history to Messages API format. Tool
+// results go back as user content; consecutive same-role messages are merged
+// because the API expects user and assistant turns to alternate.
+func (p *AnthropicProvider) buildMessages(history []chat.Message) []map[string]interface{} {
+	messages := []map[string]interface{}{}
 
-		if isInfraGenerationToolAnthropic(currentToolName) && !ag.InfraGeneration {
-			return fmt.Errorf("tool %s not available; enable infra generation in config", currentToolName)
+	for _, msg := range history {
+		role := "user"
+		if msg.Role == chat.RoleAssistant {
+			role = "assistant"
 		}
 
-		toolResult, isError := llmprotocol.ExecuteTool(ag, &chat.ToolCall{
-			ServerID:   toolInfo.ServerID,
-			ToolID:     currentToolName,
-			Handler:    toolInfo.Handler,
-			Parameters: currentToolParams,
-		})
-
-		truncatetoolparams := make(map[string]interface{})
-		for k, v := range currentToolParams {
-			if strVal, ok := v.(string); ok && len(strVal) > 100 {
-				truncatetoolparams[k] = strVal[:100] + "..."
-			} else {
-				truncatetoolparams[k] = v
+		content := []map[string]interface{}{}
+		for _, block := range msg.Blocks {
+			if converted := p.buildContentBlock(block); converted != nil {
+				content = append(content, converted)
 			}
 		}
-		fmt.Println("Calling tool:", currentToolName, "with params:", truncatetoolparams)
-
-		// Build the completed tool cycle message
-		toolMsg := chat.Message{
-			Role: "assistant",
-			ToolCall: &chat.ToolCall{
-				ServerID:   toolInfo.ServerID,
-				ToolID:     currentToolName,
-				Handler:    toolInfo.Handler,
-				Parameters: currentToolParams,
-				Reasoning:  "",
-				ToolUseID:  currentToolCallID,
-			},
-			ToolResult: &chat.ToolResult{
-				ServerID:  toolInfo.ServerID,
-				ToolID:    currentToolName,
-				Content:   toolResult,
-				IsError:   isError,
-				ToolUseID: currentToolCallID,
-			},
+		if len(content) == 0 {
+			continue
 		}
 
-		if p.OnToolCall != nil {
-			p.OnToolCall(toolMsg)
+		if n := len(messages); n > 0 && messages[n-1]["role"] == role {
+			previous := messages[n-1]["content"].([]map[string]interface{})
+			messages[n-1]["content"] = append(previous, content...)
+			continue
 		}
 
-		messages = p.buildMessages(c)
-
 		messages = append(messages, map[string]interface{}{
-			"role": "assistant",
-			"content": []map[string]interface{}{
-				{
-					"type":  "tool_use",
-					"id":    currentToolCallID,
-					"name":  currentToolName,
-					"input": currentToolParams,
-				},
-			},
+			"role":    role,
+			"content": content,
 		})
+	}
 
-		messages = append(messages, map[string]interface{}{
-			"role": "user",
-			"content": []map[string]interface{}{
-				{
-					"type":        "tool_result",
-					"tool_use_id": currentToolCallID,
-					"content":     toolResult,
-					"is_error":    isError,
-				},
-			},
-		})
+	return messages
+}
 
-		requestBody["messages"] = messages
-		response, err = p.sendHTTPRequest(requestBody)
-		if err != nil {
-			return err
+// buildContentBlock converts one chat block to a Messages API content block
+func (p *AnthropicProvider) buildContentBlock(block chat.ContentBlock) map[string]interface{} {
+	switch block.Type {
+	case chat.BlockText:
+		if block.Text == "" {
+			return nil
 		}
-
-		responseText, toolCallID, toolName, toolParams, stopReason, err = p.parseResponse(response)
-		if err != nil {
-			return err
+		return map[string]interface{}{
+			"type": "text",
+			"text": block.Text,
 		}
 
-		// Save to chat history after callback so history is consistent
-		c.AddAssistantMessage(
-			responseText,
-			toolMsg.ToolCall,
-			toolMsg.ToolResult,
-		)
-	}
-
-	if !toolsProcessed {
-		c.AddAssistantMessage(responseText, nil, nil)
-	}
+	case chat.BlockThinking:
+		// Thinking is only accepted back with the signature Claude issued
+		if block.Signature == "" {
+			return nil
+		}
+		return map[string]interface{}{
+			"type":      "thinking",
+			"thinking":  block.Text,
+			"signature": block.Signature,
+		}
 
-	return nil
-}
+	case chat.BlockToolCall:
+		if block.ToolCall == nil {
+			return nil
+		}
+		input := block.ToolCall.Parameters
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		return map[string]interface{}{
+			"type":  "tool_use",
+			"id":    block.ToolCall.ToolUseID,
+			"name":  block.ToolCall.ToolID,
+			"input": input,
+		}
 
-func (p *AnthropicProvider) buildMessages(c *chat.Chat) []map[string]interface{} {
-	messages := []map[string]interface{}{}
+	case chat.BlockToolResult:
+		if block.ToolResult == nil {
+			return nil
+		}
+		return map[string]interface{}{
+			"type":        "tool_result",
+			"tool_use_id": block.ToolResult.ToolUseID,
+			"content":     block.ToolResult.Content,
+			"is_error":    block.ToolResult.IsError,
+		}
 
-	for _, msg := range c.GetMessages() {
-		switch msg.Role {
-		case "user":
-			messages = append(messages, map[string]interface{}{
-				"role":    "user",
-				"content": msg.Content,
-			})
-
-		case "assistant":
-			if msg.ToolCall != nil && msg.ToolResult != nil {
-				messages = append(messages, map[string]interface{}{
-					"role": "assistant",
-					"content": []map[string]interface{}{
-						{
-							"type":  "tool_use",
-							"id":    msg.ToolCall.ToolUseID,
-							"name":  msg.ToolCall.ToolID,
-							"input": msg.ToolCall.Parameters,
-						},
-					},
-				})
-
-				messages = append(messages, map[string]interface{}{
-					"role": "user",
-					"content": []map[string]interface{}{
-						{
-							"type":        "tool_result",
-							"tool_use_id": msg.ToolResult.ToolUseID,
-							"content":     msg.ToolResult.Content,
-							"is_error":    msg.ToolResult.IsError,
-						},
-					},
-				})
-
-				if msg.Content != "" {
-					messages = append(messages, map[string]interface{}{
-						"role": "assistant",
-						"content": []map[string]interface{}{
-							{
-								"type": "text",
-								"text": msg.Content,
-							},
-						},
-					})
-				}
-			} else {
-				content := []map[string]interface{}{}
-				if msg.Content != "" {
-					content = append(content, map[string]interface{}{
-						"type": "text",
-						"text": msg.Content,
-					})
-				}
-				if len(content) > 0 {
-					messages = append(messages, map[string]interface{}{
-						"role":    "assistant",
-						"content": content,
-					})
-				}
+	case chat.BlockImage:
+		if block.Image == nil {
+			return nil
+		}
+		source := map[string]interface{}{
+			"type":       "base64",
+			"media_type": block.Image.MediaType,
+			"data":       block.Image.Data,
+		}
+		if block.Image.URL != "" {
+			source = map[string]interface{}{
+				"type": "url",
+				"url":  block.Image.URL,
 			}
 		}
+		return map[string]interface{}{
+			"type":   "image",
+			"source": source,
+		}
 	}
 
-	return messages
+	return nil
 }
 
-func (p *AnthropicProvider) buildTools(availableTools map[string]llmprotocol.ToolInfo, ag *agent.Agent) []map[string]interface{} {
-	tools := []map[string]interface{}{}
-
-	for toolID, toolInfo := range availableTools {
-		formatschema := toolInfo.Schema
-		formatschema["type"] = "object"
+func (p *AnthropicProvider) buildTools(specs []llmprotocol.ToolSpec) []map[string]interface{} {
+	tools := make([]map[string]interface{}, 0, len(specs))
+	for _, spec := range specs {
 		tools = append(tools, map[string]interface{}{
-			"name":         toolID,
-			"description":  toolInfo.Description,
-			"input_schema": formatschema,
+			"name":         spec.Name,
+			"description":  spec.Description,
+			"input_schema": spec.InputSchema,
 		})
 	}
-
-	if ag.ServerGeneration {
-		tools = append(tools, servergeneration.AnthropicToolSpecs()...)
-	}
-
-	if ag.InfraGeneration {
-		tools = append(tools, infrageneration.AnthropicToolSpecs()...)
-	}
-
 	return tools
 }
 
-func (p *AnthropicProvider) sendHTTPRequest(requestBody map[string]interface{}) (map[string]interface{}, error) {
+// sendHTTPRequest posts to the Messages API. With a non-nil onEvent the
+// response is streamed and reassembled into the same shape as a regular one.
+func (p *AnthropicProvider) sendHTTPRequest(ctx context.Context, requestBody map[string]interface{}, onEvent StreamHandler) (map[string]interface{}, error) {
+	if onEvent != nil {
+		streamBody := make(map[string]interface{}, len(requestBody)+1)
+		for k, v := range requestBody {
+			streamBody[k] = v
+		}
+		streamBody["stream"] = true
+		requestBody = streamBody
+	}
+
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	endpoint := p.BaseURL
+	if endpoint == "" {
+		endpoint = anthropicBaseURL
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", p.APIKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("x-api-key", p.APIKey)
+	header.Set("anthropic-version", "2023-06-01")
+	if onEvent != nil {
+		header.Set("Accept", "text/event-stream")
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := newHTTPClient(p.GetProviderName(), p.MaxRetries, p.Timeout).post(ctx, endpoint+"/messages", header, jsonData, onEvent != nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if onEvent != nil {
+		return p.readStream(resp.Body, onEvent)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
 	var response map[string]interface{}
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
@@ -317,39 +284,168 @@ func (p *AnthropicProvider) sendHTTPRequest(requestBody map[string]interface{})
 	return response, nil
 }
 
-func (p *AnthropicProvider) parseResponse(response map[string]interface{}) (string, string, string, map[string]interface{}, string, error) {
+// readStream consumes Messages API server-sent events, forwarding deltas to
+// onEvent, and returns the assembled message
+func (p *AnthropicProvider) readStream(body io.Reader, onEvent StreamHandler) (map[string]interface{}, error) {
+	response := map[string]interface{}{}
+	blocks := []map[string]interface{}{}
+	partialInput := map[int]*strings.Builder{}
+
+	err := readSSE(body, func(_, data string) error {
+		var event map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("failed to parse stream event: %w", err)
+		}
+
+		switch event["type"] {
+		case "message_start":
+			if message, ok := event["message"].(map[string]interface{}); ok {
+				response = message
+			}
+
+		case "content_block_start":
+			index := streamIndex(event)
+			block, _ := event["content_block"].(map[string]interface{})
+			if block == nil {
+				block = map[string]interface{}{}
+			}
+			for len(blocks) <= index {
+				blocks = append(blocks, map[string]interface{}{})
+			}
+			blocks[index] = block
+			if block["type"] == "tool_use" {
+				partialInput[index] = &strings.Builder{}
+			}
+
+		case "content_block_delta":
+			index := streamIndex(event)
+			delta, _ := event["delta"].(map[string]interface{})
+			if index >= len(blocks) || delta == nil {
+				return nil
+			}
+			block := blocks[index]
+
+			switch delta["type"] {
+			case "text_delta":
+				text, _ := delta["text"].(string)
+				existing, _ := block["text"].(string)
+				block["text"] = existing + text
+				onEvent(StreamEvent{Type: StreamText, Text: text})
+			case "input_json_delta":
+				fragment, _ := delta["partial_json"].(string)
+				if builder, ok := partialInput[index]; ok {
+					builder.WriteString(fragment)
+				}
+				id, _ := block["id"].(string)
+				name, _ := block["name"].(string)
+				onEvent(StreamEvent{Type: StreamToolInput, ToolUseID: id, ToolName: name, PartialJSON: fragment})
+			case "thinking_delta":
+				thinking, _ := delta["thinking"].(string)
+				existing, _ := block["thinking"].(string)
+				block["thinking"] = existing + thinking
+			case "signature_delta":
+				signature, _ := delta["signature"].(string)
+				existing, _ := block["signature"].(string)
+				block["signature"] = existing + signature
+			}
+
+		case "content_block_stop":
+			index := streamIndex(event)
+			builder, ok := partialInput[index]
+			if !ok || index >= len(blocks) {
+				return nil
+			}
+			input := map[string]interface{}{}
+			if builder.Len() > 0 {
+				if err := json.Unmarshal([]byte(builder.String()), &input); err != nil {
+					return fmt.Errorf("failed to parse streamed tool input: %w", err)
+				}
+			}
+			blocks[index]["input"] = input
+
+		case "message_delta":
+			if delta, ok := event["delta"].(map[string]interface{}); ok {
+				if stopReason, ok := delta["stop_reason"].(string); ok {
+					response["stop_reason"] = stopReason
+				}
+			}
+			if usage, ok := event["usage"].(map[string]interface{}); ok {
+				merged, _ := response["usage"].(map[string]interface{})
+				if merged == nil {
+					merged = map[string]interface{}{}
+				}
+				for k, v := range usage {
+					merged[k] = v
+				}
+				response["usage"] = merged
+			}
+
+		case "error":
+			if apiErr, ok := event["error"].(map[string]interface{}); ok {
+				return &APIError{Provider: p.GetProviderName(), Body: fmt.Sprintf("%v: %v", apiErr["type"], apiErr["message"])}
+			}
+			return &APIError{Provider: p.GetProviderName(), Body: data}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	content := make([]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		content = append(content, block)
+	}
+	response["content"] = content
+	if _, ok := response["stop_reason"].(string); !ok {
+		response["stop_reason"] = ""
+	}
+
+	return response, nil
+}
+
+func streamIndex(event map[string]interface{}) int {
+	index, _ := event["index"].(float64)
+	return int(index)
+}
+
+// parseResponse converts the response content to chat blocks, keeping the
+// order Claude produced them in, and returns the stop reason
+func (p *AnthropicProvider) parseResponse(response map[string]interface{}) ([]chat.ContentBlock, string, error) {
 	content, ok := response["content"].([]interface{})
 	if !ok || len(content) == 0 {
-		return "", "", "", nil, "", fmt.Errorf("no content in response")
+		return nil, "", fmt.Errorf("no content in response")
 	}
 
-	stopReason := response["stop_reason"].(string)
-	responseText := ""
-	toolCallID := ""
-	toolName := ""
-	var toolParams map[string]interface{}
+	stopReason, _ := response["stop_reason"].(string)
+	blocks := []chat.ContentBlock{}
 
 	for _, block := range content {
 		blockMap := block.(map[string]interface{})
-		blockType := blockMap["type"].(string)
+		blockType, _ := blockMap["type"].(string)
 
 		switch blockType {
 		case "text":
-			responseText = blockMap["text"].(string)
+			if text, _ := blockMap["text"].(string); text != "" {
+				blocks = append(blocks, chat.TextBlock(text))
+			}
+		case "thinking":
+			thinking, _ := blockMap["thinking"].(string)
+			signature, _ := blockMap["signature"].(string)
+			blocks = append(blocks, chat.ThinkingBlock(thinking, signature))
 		case "tool_use":
-			toolCallID = blockMap["id"].(string)
-			toolName = blockMap["name"].(string)
-			toolParams = blockMap["input"].(map[string]interface{})
+			input, _ := blockMap["input"].(map[string]interface{})
+			if input == nil {
+				input = map[string]interface{}{}
+			}
+			blocks = append(blocks, chat.ToolCallBlock(chat.ToolCall{
+				ToolID:     blockMap["name"].(string),
+				Parameters: input,
+				ToolUseID:  blockMap["id"].(string),
+			}))
 		}
 	}
 
-	return responseText, toolCallID, toolName, toolParams, stopReason, nil
-}
-
-func isServerGenerationToolAnthropic(name string) bool {
-	return servergeneration.IsServerGenerationTool(name)
-}
-
-func isInfraGenerationToolAnthropic(name string) bool {
-	return infrageneration.IsInfraGenerationTool(name)
+	return blocks, stopReason, nil
 }
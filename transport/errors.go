@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+)
+
+// APIError is an error reported by an LLM API, either as a non-200 response
+// or as an error event inside a stream
+type APIError struct {
+	Provider   string
+	StatusCode int // 0 for errors reported inside a stream
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("API stream error: %s", e.Body)
+	}
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// Phrases the providers use when the prompt doesn't fit the context window
+var contextLengthMarkers = []string{
+	"context_length_exceeded",
+	"maximum context length",
+	"prompt is too long",
+	"input is too long",
+	"exceeds the maximum number of tokens",
+	"input token count",
+}
+
+// Class sorts the error into one of the agent.ErrorClass values, or "" when
+// it fits none of them
+func (e *APIError) Class() string {
+	body := strings.ToLower(e.Body)
+
+	switch {
+	case e.StatusCode == 429 || strings.Contains(body, "rate_limit") || strings.Contains(body, "resource_exhausted"):
+		return agent.ErrorClassRateLimit
+	case e.StatusCode == 529 || e.StatusCode == 503 || strings.Contains(body, "overloaded"):
+		return agent.ErrorClassOverloaded
+	}
+
+	for _, marker := range contextLengthMarkers {
+		if strings.Contains(body, marker) {
+			return agent.ErrorClassContextLength
+		}
+	}
+
+	if e.StatusCode >= 500 || (e.StatusCode == 0 && strings.Contains(body, "api_error")) {
+		return agent.ErrorClassServer
+	}
+	return ""
+}
+
+// ErrorClass classifies an error returned by a provider. Failures to reach
+// the API count as server errors; cancellation and local errors have no class.
+func ErrorClass(err error) string {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return ""
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Class()
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return agent.ErrorClassServer
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"fmt"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
+)
+
+// requestBodyBuilder is implemented by providers that can show the request
+// body they would send
+type requestBodyBuilder interface {
+	BuildRequestBody(req *llmprotocol.Request) map[string]interface{}
+}
+
+// RequestPayload returns the body the agent's next LLM call would send for
+// the chat, in the wire format of providerName (empty means the agent's own
+// provider). The system prompt and tools come from ag; no API key is needed.
+func RequestPayload(providerName string, c *chat.Chat, ag *agent.Agent) (map[string]interface{}, error) {
+	llmConfig := *ag.LLMConfig
+	llmConfig.Fallbacks = nil
+	if providerName != "" {
+		llmConfig.Provider = providerName
+	}
+
+	provider, err := NewProvider(&llmConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	builder, ok := provider.(requestBodyBuilder)
+	if !ok {
+		return nil, fmt.Errorf("provider %s cannot export request payloads", provider.GetProviderName())
+	}
+	return builder.BuildRequestBody(llmprotocol.NextRequest(c, ag)), nil
+}
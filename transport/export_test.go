@@ -0,0 +1,74 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/registry"
+)
+
+// exportTestChat holds one of every block kind, a summary and a finished tool round
+func exportTestChat() *chat.Chat {
+	c := chat.NewChat("export-test", 0)
+	c.SetSummary("The user is building a weather server.")
+	c.AddMessage(chat.RoleUser,
+		chat.TextBlock("What's the weather in Paris, and what's in this picture?"),
+		chat.ImageBlock(chat.Image{MediaType: "image/png", Data: "aGVsbG8="}),
+	)
+	c.AddAssistantReply(&chat.Usage{Model: "gpt-4o", Calls: 1, InputTokens: 120, OutputTokens: 30},
+		chat.ThinkingBlock("Look up the weather first.", "sig-1"),
+		chat.TextBlock("Let me check."),
+		chat.ToolCallBlock(chat.ToolCall{
+			ToolID:     "get_weather",
+			ToolUseID:  "call_1",
+			ServerID:   "weather",
+			Parameters: map[string]interface{}{"city": "Paris", "days": float64(2)},
+		}),
+	)
+	c.AddToolResults(chat.ToolResult{ServerID: "weather", ToolID: "get_weather", ToolUseID: "call_1", Content: `{"temp": 18}`})
+	c.AddAssistantMessage(chat.TextBlock("It's 18°C in Paris; the picture says hello."))
+	return c
+}
+
+func TestJSONLRoundTripKeepsRequestPayloads(t *testing.T) {
+	original := exportTestChat()
+
+	var exported bytes.Buffer
+	if err := original.WriteJSONL(&exported); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := chat.ReadJSONL(&exported)
+	if err != nil {
+		t.Fatalf("ReadJSONL: %v", err)
+	}
+
+	ag := agent.NewAgent("tester", "Test agent", registry.NewRegistry(), &agent.LLMConfig{
+		APIKey:      "test",
+		Model:       "gpt-4o",
+		Temperature: 0.5,
+		MaxTokens:   100,
+	}, false, false, false)
+
+	for _, provider := range []string{agent.ProviderAnthropic, agent.ProviderOpenAI, agent.ProviderGemini} {
+		want, err := RequestPayload(provider, original, ag)
+		if err != nil {
+			t.Fatalf("%s: %v", provider, err)
+		}
+		got, err := RequestPayload(provider, imported, ag)
+		if err != nil {
+			t.Fatalf("%s: %v", provider, err)
+		}
+
+		wantJSON, _ := json.Marshal(want)
+		gotJSON, _ := json.Marshal(got)
+		if !bytes.Contains(wantJSON, []byte("get_weather")) || !bytes.Contains(wantJSON, []byte("18")) {
+			t.Fatalf("%s payload is missing the tool round: %s", provider, wantJSON)
+		}
+		if !bytes.Equal(gotJSON, wantJSON) {
+			t.Fatalf("%s payload changed in the round trip:\n got %s\nwant %s", provider, gotJSON, wantJSON)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
+)
+
+// adapterProvider is a provider whose single LLM calls can be driven by
+// llmprotocol.RunTurn; every built-in provider is one
+type adapterProvider interface {
+	Provider
+	llmprotocol.Adapter
+}
+
+// fallbackLink is one model in a FallbackProvider chain
+type fallbackLink struct {
+	provider adapterProvider
+	model    string
+	on       []string // error classes that move the chain to this link; unused for the primary
+}
+
+// FallbackProvider runs turns on a primary model and moves each failed LLM
+// call down an ordered chain of other models. Failover happens per call, so
+// tools that already ran in the turn are never repeated.
+type FallbackProvider struct {
+	links []fallbackLink
+	// Optional tool progress callbacks. Used by the HTTP server to broadcast over WS.
+	llmprotocol.Hooks
+}
+
+// NewFallbackProvider builds the chain for llmConfig and its Fallbacks
+func NewFallbackProvider(llmConfig *agent.LLMConfig) (*FallbackProvider, error) {
+	primaryConfig := *llmConfig
+	primaryConfig.Fallbacks = nil
+
+	primary, err := newAdapterProvider(&primaryConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &FallbackProvider{
+		links: []fallbackLink{{provider: primary, model: llmConfig.Model}},
+	}
+
+	for _, fallback := range llmConfig.Fallbacks {
+		fallbackConfig := fallback.LLM
+		provider, err := newAdapterProvider(&fallbackConfig)
+		if err != nil {
+			return nil, fmt.Errorf("fallback %s: %w", fallbackConfig.Model, err)
+		}
+
+		on := fallback.On
+		if len(on) == 0 {
+			on = agent.DefaultFallbackOn
+		}
+		p.links = append(p.links, fallbackLink{provider: provider, model: fallbackConfig.Model, on: on})
+	}
+
+	return p, nil
+}
+
+func newAdapterProvider(llmConfig *agent.LLMConfig) (adapterProvider, error) {
+	provider, err := NewProvider(llmConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	adapter, ok := provider.(adapterProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %s cannot be used in a fallback chain", provider.GetProviderName())
+	}
+	return adapter, nil
+}
+
+// SetToolCallbacks installs the tool progress callbacks
+func (p *FallbackProvider) SetToolCallbacks(onStart func(call chat.ToolCall), onResult func(call chat.ToolCall, result chat.ToolResult)) {
+	p.OnToolStart = onStart
+	p.OnToolResult = onResult
+}
+
+// GetProviderName lists the chain, e.g. "anthropic -> openai"
+func (p *FallbackProvider) GetProviderName() string {
+	names := make([]string, 0, len(p.links))
+	for _, link := range p.links {
+		names = append(names, link.provider.GetProviderName())
+	}
+	return strings.Join(names, " -> ")
+}
+
+func (p *FallbackProvider) SendRequest(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string) error {
+	return llmprotocol.RunTurn(ctx, p, c, ag, userMessage, p.Hooks, nil)
+}
+
+func (p *FallbackProvider) SendRequestStream(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string, onEvent StreamHandler) error {
+	return llmprotocol.RunTurn(ctx, p, c, ag, userMessage, p.Hooks, onEvent)
+}
+
+// Complete tries the primary model, then each link whose rules match the
+// last error's class. A streamed call that already produced output is not
+// retried elsewhere, since the caller has shown part of the reply. A call
+// that names its own model, such as a summary, uses it on the primary only;
+// the other links answer it with their own model.
+func (p *FallbackProvider) Complete(ctx context.Context, req *llmprotocol.Request) (*llmprotocol.Response, error) {
+	var lastErr error
+
+	for i, link := range p.links {
+		if i > 0 && !containsClass(link.on, ErrorClass(lastErr)) {
+			continue
+		}
+
+		attempt := *req
+		if i > 0 {
+			attempt.Model = ""
+		}
+		streamed := false
+		if req.OnEvent != nil {
+			attempt.OnEvent = func(event StreamEvent) {
+				streamed = true
+				req.OnEvent(event)
+			}
+		}
+
+		resp, err := link.provider.Complete(ctx, &attempt)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil || streamed || ErrorClass(err) == "" {
+			return nil, err
+		}
+
+		log.Printf("LLM call to %s failed (%s): %v", link.model, ErrorClass(err), err)
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+func containsClass(classes []string, class string) bool {
+	for _, c := range classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"context"
+	"testing"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
+)
+
+// fakeLink fails every call with err, or answers with its name, recording
+// the model each call asked for
+type fakeLink struct {
+	name   string
+	err    error
+	models []string
+}
+
+func (l *fakeLink) Complete(ctx context.Context, req *llmprotocol.Request) (*llmprotocol.Response, error) {
+	l.models = append(l.models, req.Model)
+	if l.err != nil {
+		return nil, l.err
+	}
+	return &llmprotocol.Response{Blocks: []chat.ContentBlock{chat.TextBlock(l.name)}, StopReason: llmprotocol.StopEndTurn}, nil
+}
+
+func (l *fakeLink) SendRequest(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string) error {
+	return nil
+}
+
+func (l *fakeLink) SendRequestStream(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string, onEvent StreamHandler) error {
+	return nil
+}
+
+func (l *fakeLink) GetProviderName() string { return l.name }
+
+func fallbackChain(links ...*fakeLink) *FallbackProvider {
+	p := &FallbackProvider{}
+	for i, link := range links {
+		fl := fallbackLink{provider: link, model: link.name}
+		if i > 0 {
+			fl.on = agent.DefaultFallbackOn
+		}
+		p.links = append(p.links, fl)
+	}
+	return p
+}
+
+func TestFallbackMovesFailedCallDownTheChain(t *testing.T) {
+	primary := &fakeLink{name: "primary", err: &APIError{StatusCode: 529, Body: "overloaded"}}
+	backup := &fakeLink{name: "backup"}
+	p := fallbackChain(primary, backup)
+
+	resp, err := p.Complete(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatalf("Complete = %v, want the backup's reply", err)
+	}
+	if got := (chat.Message{Blocks: resp.Blocks}).Text(); got != "backup" {
+		t.Fatalf("reply = %q, want backup", got)
+	}
+}
+
+func TestFallbackSkipsLinksForOtherErrorClasses(t *testing.T) {
+	primary := &fakeLink{name: "primary", err: &APIError{StatusCode: 400, Body: "prompt is too long"}}
+	backup := &fakeLink{name: "backup"}
+	p := fallbackChain(primary, backup)
+
+	if _, err := p.Complete(context.Background(), userRequest("hi")); err == nil {
+		t.Fatal("Complete = nil, want the context_length error")
+	}
+	if len(backup.models) != 0 {
+		t.Fatalf("backup called %d times, want 0", len(backup.models))
+	}
+}
+
+func TestFallbackAnswersSummaryWithOwnModel(t *testing.T) {
+	primary := &fakeLink{name: "primary", err: &APIError{StatusCode: 429, Body: "rate_limit"}}
+	backup := &fakeLink{name: "backup"}
+	p := fallbackChain(primary, backup)
+
+	req := userRequest("summarize")
+	req.Model = "summary-model"
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete = %v, want the backup's reply", err)
+	}
+	if len(primary.models) != 1 || primary.models[0] != "summary-model" {
+		t.Fatalf("primary asked for %q, want summary-model", primary.models)
+	}
+	if len(backup.models) != 1 || backup.models[0] != "" {
+		t.Fatalf("backup asked for %q, want its own model", backup.models)
+	}
+}
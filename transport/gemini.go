@@ -0,0 +1,490 @@
+// transport/gemini.go
+package transport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
+)
+
+const geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// Gemini only sometimes returns ids for function calls; the ones we make up
+// carry this prefix so they are never sent back to the API
+const geminiCallIDPrefix = "gemini-call-"
+
+type GeminiProvider struct {
+	APIKey      string
+	Model       string
+	Temperature float32
+	MaxTokens   int
+	// MaxRetries and Timeout tune the HTTP layer; zero uses the agent defaults
+	MaxRetries int
+	Timeout    time.Duration
+	// BaseURL is the API root, without the /models path
+	BaseURL string
+	// Optional tool progress callbacks. Used by the HTTP server to broadcast over WS.
+	llmprotocol.Hooks
+}
+
+func NewGeminiProvider(config *agent.LLMConfig) *GeminiProvider {
+	return &GeminiProvider{
+		APIKey:      config.APIKey,
+		Model:       config.Model,
+		Temperature: config.Temperature,
+		MaxTokens:   config.MaxTokens,
+		MaxRetries:  config.MaxRetries,
+		Timeout:     config.Timeout,
+		BaseURL:     baseURL(config, geminiBaseURL),
+	}
+}
+
+// SetToolCallbacks installs the tool progress callbacks
+func (p *GeminiProvider) SetToolCallbacks(onStart func(call chat.ToolCall), onResult func(call chat.ToolCall, result chat.ToolResult)) {
+	p.OnToolStart = onStart
+	p.OnToolResult = onResult
+}
+
+func (p *GeminiProvider) GetProviderName() string {
+	return agent.ProviderGemini
+}
+
+func (p *GeminiProvider) SendRequest(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string) error {
+	return llmprotocol.RunTurn(ctx, p, c, ag, userMessage, p.Hooks, nil)
+}
+
+func (p *GeminiProvider) SendRequestStream(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string, onEvent StreamHandler) error {
+	return llmprotocol.RunTurn(ctx, p, c, ag, userMessage, p.Hooks, onEvent)
+}
+
+// Complete sends one generateContent request; a non-nil req.OnEvent uses
+// streamGenerateContent instead
+func (p *GeminiProvider) Complete(ctx context.Context, req *llmprotocol.Request) (*llmprotocol.Response, error) {
+	model := req.ModelOr(p.Model)
+	requestBody := p.BuildRequestBody(req)
+
+	response, err := p.sendHTTPRequest(ctx, model, requestBody, req.OnEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, finishReason, err := p.parseResponse(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &llmprotocol.Response{Blocks: blocks, StopReason: geminiStopReason(finishReason, blocks), Usage: p.parseUsage(response, model)}, nil
+}
+
+// BuildRequestBody encodes req as a generateContent request body; the model
+// goes in the URL rather than the body
+func (p *GeminiProvider) BuildRequestBody(req *llmprotocol.Request) map[string]interface{} {
+	requestBody := map[string]interface{}{
+		"contents": p.buildContents(req.Messages),
+		"generationConfig": map[string]interface{}{
+			"temperature":     p.Temperature,
+			"maxOutputTokens": p.MaxTokens,
+		},
+	}
+
+	if req.System != "" {
+		requestBody["systemInstruction"] = map[string]interface{}{
+			"parts": []map[string]interface{}{{"text": req.System}},
+		}
+	}
+
+	if len(req.Tools) > 0 {
+		requestBody["tools"] = p.buildTools(req.Tools)
+	}
+	return requestBody
+}
+
+// parseUsage reads usageMetadata. promptTokenCount includes cached input,
+// and thinking tokens are billed as output.
+func (p *GeminiProvider) parseUsage(response map[string]interface{}, model string) *chat.Usage {
+	usage, _ := response["usageMetadata"].(map[string]interface{})
+	cached := intField(usage, "cachedContentTokenCount")
+
+	return &chat.Usage{
+		Model:           model,
+		InputTokens:     intField(usage, "promptTokenCount") - cached,
+		OutputTokens:    intField(usage, "candidatesTokenCount") + intField(usage, "thoughtsTokenCount"),
+		CacheReadTokens: cached,
+	}
+}
+
+// geminiStopReason maps a finishReason to the neutral stop reasons. Gemini
+// reports STOP for function calls, so those are detected from the blocks.
+func geminiStopReason(finishReason string, blocks []chat.ContentBlock) string {
+	if finishReason == "MAX_TOKENS" {
+		return llmprotocol.StopMaxTokens
+	}
+	for _, block := range blocks {
+		if block.Type == chat.BlockToolCall {
+			return llmprotocol.StopToolUse
+		}
+	}
+	return llmprotocol.StopEndTurn
+}
+
+// buildContents converts the chat history to Gemini contents. Tool results
+// go back as user functionResponse parts; consecutive same-role contents are
+// merged so user and model turns alternate.
+func (p *GeminiProvider) buildContents(history []chat.Message) []map[string]interface{} {
+	contents := []map[string]interface{}{}
+
+	for _, msg := range history {
+		role := "user"
+		if msg.Role == chat.RoleAssistant {
+			role = "model"
+		}
+
+		parts := []map[string]interface{}{}
+		for _, block := range msg.Blocks {
+			if part := p.buildPart(block); part != nil {
+				parts = append(parts, part)
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		if n := len(contents); n > 0 && contents[n-1]["role"] == role {
+			previous := contents[n-1]["parts"].([]map[string]interface{})
+			contents[n-1]["parts"] = append(previous, parts...)
+			continue
+		}
+
+		contents = append(contents, map[string]interface{}{
+			"role":  role,
+			"parts": parts,
+		})
+	}
+
+	return contents
+}
+
+// buildPart converts one chat block to a Gemini part
+func (p *GeminiProvider) buildPart(block chat.ContentBlock) map[string]interface{} {
+	var part map[string]interface{}
+
+	switch block.Type {
+	case chat.BlockText:
+		if block.Text == "" {
+			return nil
+		}
+		part = map[string]interface{}{"text": block.Text}
+
+	case chat.BlockToolCall:
+		if block.ToolCall == nil {
+			return nil
+		}
+		args := block.ToolCall.Parameters
+		if args == nil {
+			args = map[string]interface{}{}
+		}
+		call := map[string]interface{}{
+			"name": block.ToolCall.ToolID,
+			"args": args,
+		}
+		if id := block.ToolCall.ToolUseID; id != "" && !strings.HasPrefix(id, geminiCallIDPrefix) {
+			call["id"] = id
+		}
+		part = map[string]interface{}{"functionCall": call}
+
+	case chat.BlockToolResult:
+		if block.ToolResult == nil {
+			return nil
+		}
+		response := map[string]interface{}{"content": block.ToolResult.Content}
+		if block.ToolResult.IsError {
+			response = map[string]interface{}{"error": block.ToolResult.Content}
+		}
+		result := map[string]interface{}{
+			"name":     block.ToolResult.ToolID,
+			"response": response,
+		}
+		if id := block.ToolResult.ToolUseID; id != "" && !strings.HasPrefix(id, geminiCallIDPrefix) {
+			result["id"] = id
+		}
+		part = map[string]interface{}{"functionResponse": result}
+
+	case chat.BlockImage:
+		if block.Image == nil {
+			return nil
+		}
+		if block.Image.URL != "" {
+			part = map[string]interface{}{
+				"fileData": map[string]interface{}{
+					"mimeType": block.Image.MediaType,
+					"fileUri":  block.Image.URL,
+				},
+			}
+		} else {
+			part = map[string]interface{}{
+				"inlineData": map[string]interface{}{
+					"mimeType": block.Image.MediaType,
+					"data":     block.Image.Data,
+				},
+			}
+		}
+
+	default:
+		// Thought summaries are not replayed
+		return nil
+	}
+
+	if block.Signature != "" {
+		part["thoughtSignature"] = block.Signature
+	}
+	return part
+}
+
+func (p *GeminiProvider) buildTools(specs []llmprotocol.ToolSpec) []map[string]interface{} {
+	declarations := make([]map[string]interface{}, 0, len(specs))
+	for _, spec := range specs {
+		declarations = append(declarations, map[string]interface{}{
+			"name":        spec.Name,
+			"description": spec.Description,
+			"parameters":  spec.InputSchema,
+		})
+	}
+	return []map[string]interface{}{{"functionDeclarations": declarations}}
+}
+
+// sendHTTPRequest posts to generateContent. With a non-nil onEvent it uses
+// streamGenerateContent and merges the chunks into the same shape as a
+// regular response.
+func (p *GeminiProvider) sendHTTPRequest(ctx context.Context, model string, requestBody map[string]interface{}, onEvent StreamHandler) (map[string]interface{}, error) {
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := p.BaseURL
+	if endpoint == "" {
+		endpoint = geminiBaseURL
+	}
+
+	url := fmt.Sprintf("%s/models/%s:generateContent", endpoint, model)
+	if onEvent != nil {
+		url = fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", endpoint, model)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("x-goog-api-key", p.APIKey)
+	if onEvent != nil {
+		header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := newHTTPClient(p.GetProviderName(), p.MaxRetries, p.Timeout).post(ctx, url, header, jsonData, onEvent != nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if onEvent != nil {
+		return p.readStream(resp.Body, onEvent)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return response, nil
+}
+
+// readStream consumes streamGenerateContent chunks, forwarding text to
+// onEvent, and returns a response with every part in one candidate.
+// Function calls arrive whole, so their arguments are sent as a single event.
+func (p *GeminiProvider) readStream(body io.Reader, onEvent StreamHandler) (map[string]interface{}, error) {
+	response := map[string]interface{}{}
+	parts := []interface{}{}
+	finishReason := ""
+
+	err := readSSE(body, func(_, data string) error {
+		var chunk map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+
+		if apiErr, ok := chunk["error"].(map[string]interface{}); ok {
+			return &APIError{Provider: p.GetProviderName(), Body: fmt.Sprintf("%v: %v", apiErr["status"], apiErr["message"])}
+		}
+
+		for _, key := range []string{"usageMetadata", "modelVersion", "promptFeedback"} {
+			if value, ok := chunk[key]; ok && value != nil {
+				response[key] = value
+			}
+		}
+
+		candidates, _ := chunk["candidates"].([]interface{})
+		if len(candidates) == 0 {
+			return nil
+		}
+		candidate, _ := candidates[0].(map[string]interface{})
+		if reason, ok := candidate["finishReason"].(string); ok {
+			finishReason = reason
+		}
+
+		content, _ := candidate["content"].(map[string]interface{})
+		chunkParts, _ := content["parts"].([]interface{})
+		for _, raw := range chunkParts {
+			part, _ := raw.(map[string]interface{})
+			if part == nil {
+				continue
+			}
+
+			if text, ok := part["text"].(string); ok {
+				thought, _ := part["thought"].(bool)
+				if !thought && text != "" {
+					onEvent(StreamEvent{Type: StreamText, Text: text})
+				}
+
+				// Merge into the previous part of the same kind so the
+				// assembled response has one text part per run
+				if previous := lastTextPart(parts, thought); previous != nil {
+					previous["text"] = previous["text"].(string) + text
+					if signature, ok := part["thoughtSignature"]; ok {
+						previous["thoughtSignature"] = signature
+					}
+					continue
+				}
+			}
+
+			if call, ok := part["functionCall"].(map[string]interface{}); ok {
+				name, _ := call["name"].(string)
+				id, _ := call["id"].(string)
+				args, _ := json.Marshal(call["args"])
+				onEvent(StreamEvent{Type: StreamToolInput, ToolUseID: id, ToolName: name, PartialJSON: string(args)})
+			}
+
+			parts = append(parts, part)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response["candidates"] = []interface{}{
+		map[string]interface{}{
+			"content": map[string]interface{}{
+				"role":  "model",
+				"parts": parts,
+			},
+			"finishReason": finishReason,
+		},
+	}
+
+	return response, nil
+}
+
+// lastTextPart returns the last part if it is a text part with the same thought flag
+func lastTextPart(parts []interface{}, thought bool) map[string]interface{} {
+	if len(parts) == 0 {
+		return nil
+	}
+	previous, _ := parts[len(parts)-1].(map[string]interface{})
+	if _, isText := previous["text"].(string); !isText {
+		return nil
+	}
+	if previousThought, _ := previous["thought"].(bool); previousThought != thought {
+		return nil
+	}
+	return previous
+}
+
+// parseResponse converts the first candidate's parts to chat blocks in
+// order and returns its finish reason
+func (p *GeminiProvider) parseResponse(response map[string]interface{}) ([]chat.ContentBlock, string, error) {
+	candidates, ok := response["candidates"].([]interface{})
+	if !ok || len(candidates) == 0 {
+		if feedback, ok := response["promptFeedback"].(map[string]interface{}); ok {
+			if reason, ok := feedback["blockReason"].(string); ok {
+				return nil, "", fmt.Errorf("prompt blocked: %s", reason)
+			}
+		}
+		return nil, "", fmt.Errorf("no candidates in response")
+	}
+
+	candidate := candidates[0].(map[string]interface{})
+	finishReason, _ := candidate["finishReason"].(string)
+	content, _ := candidate["content"].(map[string]interface{})
+	parts, _ := content["parts"].([]interface{})
+	if len(parts) == 0 && finishReason != "STOP" && finishReason != "MAX_TOKENS" {
+		return nil, "", fmt.Errorf("no content in response (finish reason %s)", finishReason)
+	}
+
+	blocks := []chat.ContentBlock{}
+	for _, raw := range parts {
+		part, _ := raw.(map[string]interface{})
+		if part == nil {
+			continue
+		}
+		signature, _ := part["thoughtSignature"].(string)
+
+		if call, ok := part["functionCall"].(map[string]interface{}); ok {
+			name, _ := call["name"].(string)
+			args, _ := call["args"].(map[string]interface{})
+			if args == nil {
+				args = map[string]interface{}{}
+			}
+			id, _ := call["id"].(string)
+			if id == "" {
+				id = newGeminiCallID()
+			}
+
+			block := chat.ToolCallBlock(chat.ToolCall{
+				ToolID:     name,
+				Parameters: args,
+				ToolUseID:  id,
+			})
+			block.Signature = signature
+			blocks = append(blocks, block)
+			continue
+		}
+
+		text, ok := part["text"].(string)
+		if !ok {
+			continue
+		}
+		if thought, _ := part["thought"].(bool); thought {
+			blocks = append(blocks, chat.ThinkingBlock(text, signature))
+			continue
+		}
+		if text != "" || signature != "" {
+			block := chat.TextBlock(text)
+			block.Signature = signature
+			blocks = append(blocks, block)
+		}
+	}
+
+	return blocks, finishReason, nil
+}
+
+// newGeminiCallID makes up an ID for a function call Gemini sent without one.
+// IDs are random so calls from different turns never share one.
+func newGeminiCallID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return geminiCallIDPrefix + hex.EncodeToString(buf)
+}
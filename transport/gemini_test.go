@@ -0,0 +1,235 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
+)
+
+// fakeGemini serves generateContent with reply and streamGenerateContent
+// with chunks, recording the last request body it received
+func fakeGemini(t *testing.T, reply string, chunks []string, received *map[string]interface{}) *GeminiProvider {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("x-goog-api-key"); got != "test-key" {
+			t.Errorf("x-goog-api-key = %q, want test-key", got)
+		}
+		if received != nil {
+			data, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(data, received); err != nil {
+				t.Errorf("request body is not JSON: %v", err)
+			}
+		}
+
+		switch r.URL.Path {
+		case "/models/gemini-test:generateContent":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, reply)
+		case "/models/gemini-test:streamGenerateContent":
+			if r.URL.Query().Get("alt") != "sse" {
+				t.Errorf("stream request without alt=sse: %s", r.URL)
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, chunk := range chunks {
+				io.WriteString(w, "data: "+chunk+"\n\n")
+			}
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return &GeminiProvider{
+		APIKey:      "test-key",
+		Model:       "gemini-test",
+		Temperature: 0.5,
+		MaxTokens:   256,
+		MaxRetries:  -1,
+		BaseURL:     srv.URL,
+	}
+}
+
+func userRequest(text string) *llmprotocol.Request {
+	return &llmprotocol.Request{
+		System:   "be brief",
+		Messages: []chat.Message{{Role: chat.RoleUser, Blocks: []chat.ContentBlock{chat.TextBlock(text)}}},
+	}
+}
+
+func TestGeminiFunctionCalling(t *testing.T) {
+	reply := `{
+		"candidates": [{
+			"content": {"role": "model", "parts": [
+				{"text": "Checking the weather."},
+				{"functionCall": {"name": "get_weather", "args": {"city": "Paris"}}, "thoughtSignature": "sig"}
+			]},
+			"finishReason": "STOP"
+		}],
+		"usageMetadata": {"promptTokenCount": 120, "cachedContentTokenCount": 20, "candidatesTokenCount": 15, "thoughtsTokenCount": 5}
+	}`
+	var received map[string]interface{}
+	p := fakeGemini(t, reply, nil, &received)
+
+	req := userRequest("weather in Paris?")
+	req.Tools = []llmprotocol.ToolSpec{{
+		Name:        "get_weather",
+		Description: "Current weather for a city",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}}
+
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	tools, _ := received["tools"].([]interface{})
+	if len(tools) != 1 {
+		t.Fatalf("request tools = %v, want one functionDeclarations entry", received["tools"])
+	}
+	if _, ok := received["systemInstruction"]; !ok {
+		t.Fatal("request has no systemInstruction")
+	}
+
+	if resp.StopReason != llmprotocol.StopToolUse {
+		t.Fatalf("StopReason = %q, want %q", resp.StopReason, llmprotocol.StopToolUse)
+	}
+	if len(resp.Blocks) != 2 || resp.Blocks[0].Text != "Checking the weather." {
+		t.Fatalf("blocks = %+v, want text then tool call", resp.Blocks)
+	}
+
+	call := resp.Blocks[1].ToolCall
+	if call == nil || call.ToolID != "get_weather" || call.Parameters["city"] != "Paris" {
+		t.Fatalf("tool call = %+v, want get_weather(city=Paris)", call)
+	}
+	if !strings.HasPrefix(call.ToolUseID, geminiCallIDPrefix) {
+		t.Fatalf("ToolUseID = %q, want a generated %s ID", call.ToolUseID, geminiCallIDPrefix)
+	}
+	if resp.Blocks[1].Signature != "sig" {
+		t.Fatalf("Signature = %q, want sig", resp.Blocks[1].Signature)
+	}
+
+	usage := resp.Usage
+	if usage.Model != "gemini-test" || usage.InputTokens != 100 || usage.CacheReadTokens != 20 || usage.OutputTokens != 20 {
+		t.Fatalf("usage = %+v, want 100 input, 20 cached, 20 output", usage)
+	}
+}
+
+func TestGeminiGeneratedCallIDsNotSentBack(t *testing.T) {
+	var received map[string]interface{}
+	p := fakeGemini(t, `{"candidates": [{"content": {"parts": [{"text": "Sunny."}]}, "finishReason": "STOP"}]}`, nil, &received)
+
+	call := chat.ToolCall{ToolID: "get_weather", Parameters: map[string]interface{}{"city": "Paris"}, ToolUseID: geminiCallIDPrefix + "0"}
+	req := userRequest("weather in Paris?")
+	req.Messages = append(req.Messages,
+		chat.Message{Role: chat.RoleAssistant, Blocks: []chat.ContentBlock{chat.ToolCallBlock(call)}},
+		chat.Message{Role: chat.RoleTool, Blocks: []chat.ContentBlock{chat.ToolResultBlock(chat.ToolResult{ToolID: "get_weather", ToolUseID: call.ToolUseID, Content: "sunny"})}},
+	)
+
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.StopReason != llmprotocol.StopEndTurn {
+		t.Fatalf("StopReason = %q, want %q", resp.StopReason, llmprotocol.StopEndTurn)
+	}
+
+	data, _ := json.Marshal(received["contents"])
+	if strings.Contains(string(data), geminiCallIDPrefix) {
+		t.Fatalf("generated call ID sent back to the API: %s", data)
+	}
+	if !strings.Contains(string(data), `"functionResponse"`) {
+		t.Fatalf("tool result not sent as functionResponse: %s", data)
+	}
+}
+
+func TestGeminiStreaming(t *testing.T) {
+	chunks := []string{
+		`{"candidates": [{"content": {"role": "model", "parts": [{"text": "Let me "}]}}]}`,
+		`{"candidates": [{"content": {"role": "model", "parts": [{"text": "check."}]}}]}`,
+		`{"candidates": [{"content": {"role": "model", "parts": [{"functionCall": {"id": "call-1", "name": "get_weather", "args": {"city": "Oslo"}}}]}, "finishReason": "STOP"}], "usageMetadata": {"promptTokenCount": 40, "candidatesTokenCount": 12}}`,
+	}
+	p := fakeGemini(t, "", chunks, nil)
+
+	var events []StreamEvent
+	req := userRequest("weather in Oslo?")
+	req.OnEvent = func(event StreamEvent) { events = append(events, event) }
+
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	var text strings.Builder
+	var toolInput *StreamEvent
+	for i, event := range events {
+		switch event.Type {
+		case StreamText:
+			text.WriteString(event.Text)
+		case StreamToolInput:
+			toolInput = &events[i]
+		}
+	}
+	if text.String() != "Let me check." {
+		t.Fatalf("streamed text = %q, want %q", text.String(), "Let me check.")
+	}
+	if toolInput == nil || toolInput.ToolUseID != "call-1" || toolInput.ToolName != "get_weather" || toolInput.PartialJSON != `{"city":"Oslo"}` {
+		t.Fatalf("tool input event = %+v", toolInput)
+	}
+
+	if len(resp.Blocks) != 2 || resp.Blocks[0].Text != "Let me check." {
+		t.Fatalf("blocks = %+v, want merged text then tool call", resp.Blocks)
+	}
+	if call := resp.Blocks[1].ToolCall; call == nil || call.ToolUseID != "call-1" {
+		t.Fatalf("tool call = %+v, want ID call-1", call)
+	}
+	if resp.StopReason != llmprotocol.StopToolUse {
+		t.Fatalf("StopReason = %q, want %q", resp.StopReason, llmprotocol.StopToolUse)
+	}
+	if resp.Usage.InputTokens != 40 || resp.Usage.OutputTokens != 12 {
+		t.Fatalf("usage = %+v, want 40 input, 12 output", resp.Usage)
+	}
+}
+
+func TestGeminiStreamError(t *testing.T) {
+	p := fakeGemini(t, "", []string{`{"error": {"status": "RESOURCE_EXHAUSTED", "message": "quota"}}`}, nil)
+
+	req := userRequest("hi")
+	req.OnEvent = func(StreamEvent) {}
+
+	_, err := p.Complete(context.Background(), req)
+	apiErr, ok := err.(*APIError)
+	if !ok || !strings.Contains(apiErr.Body, "RESOURCE_EXHAUSTED") {
+		t.Fatalf("err = %v, want a RESOURCE_EXHAUSTED APIError", err)
+	}
+}
+
+func TestGeminiGeneratedCallIDsAreUnique(t *testing.T) {
+	reply := `{"candidates": [{"content": {"parts": [
+		{"functionCall": {"name": "get_weather", "args": {"city": "Paris"}}},
+		{"functionCall": {"name": "get_weather", "args": {"city": "Oslo"}}}
+	]}, "finishReason": "STOP"}]}`
+	p := fakeGemini(t, reply, nil, nil)
+
+	seen := map[string]bool{}
+	for turn := 0; turn < 3; turn++ {
+		resp, err := p.Complete(context.Background(), userRequest("weather?"))
+		if err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		for _, block := range resp.Blocks {
+			id := block.ToolCall.ToolUseID
+			if seen[id] {
+				t.Fatalf("call ID %s was generated twice", id)
+			}
+			seen[id] = true
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+)
+
+// Backoff between retries doubles from initialBackoff up to maxBackoff
+const (
+	initialBackoff = 1 * time.Second
+	maxBackoff     = 30 * time.Second
+	// A server asking us to wait longer than this gets the error instead,
+	// leaving the decision to a fallback chain or the user
+	maxRetryAfter = 60 * time.Second
+)
+
+// sharedHTTP is used by every provider so connections are reused across requests
+var sharedHTTP = &http.Client{}
+
+// httpClient sends provider requests with a per-attempt timeout, retrying
+// rate limits, overloads, server errors and network failures
+type httpClient struct {
+	provider       string
+	maxRetries     int
+	timeout        time.Duration
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// newHTTPClient applies the agent defaults: maxRetries 0 means
+// agent.DefaultMaxRetries and negative disables retries; timeout 0 means
+// agent.DefaultLLMTimeout
+func newHTTPClient(provider string, maxRetries int, timeout time.Duration) *httpClient {
+	if maxRetries == 0 {
+		maxRetries = agent.DefaultMaxRetries
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if timeout <= 0 {
+		timeout = agent.DefaultLLMTimeout
+	}
+
+	return &httpClient{
+		provider:       provider,
+		maxRetries:     maxRetries,
+		timeout:        timeout,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+	}
+}
+
+// post sends body to url and returns the 200 response; the caller must close
+// its Body. Non-200 responses come back as *APIError. The same body is resent
+// on every attempt. With stream set, the timeout only covers waiting for the
+// response headers, so long streams are not cut off.
+func (h *httpClient) post(ctx context.Context, url string, header http.Header, body []byte, stream bool) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := h.attempt(ctx, url, header, body, stream)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		retryAfter := time.Duration(0)
+		retryable := true
+		if err == nil {
+			data, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			err = &APIError{Provider: h.provider, StatusCode: resp.StatusCode, Body: string(data)}
+			retryable = isRetryableStatus(resp.StatusCode)
+			retryAfter = parseRetryAfter(resp.Header)
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !retryable || attempt >= h.maxRetries || retryAfter > maxRetryAfter {
+			return nil, err
+		}
+
+		wait := retryAfter
+		if wait <= 0 {
+			wait = h.backoff(attempt)
+		}
+		log.Printf("%s request failed (%v), retrying in %s (%d/%d)", h.provider, err, wait.Round(time.Millisecond), attempt+1, h.maxRetries)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// attempt sends one request under its own timeout. The timeout is released
+// when the response body is closed, or once headers arrive for a stream.
+func (h *httpClient) attempt(ctx context.Context, url string, header http.Header, body []byte, stream bool) (*http.Response, error) {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(h.timeout, cancel)
+
+	req, err := http.NewRequestWithContext(attemptCtx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		timer.Stop()
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = header.Clone()
+
+	resp, err := sharedHTTP.Do(req)
+	if err != nil {
+		timer.Stop()
+		cancel()
+		if ctx.Err() == nil && attemptCtx.Err() != nil {
+			return nil, fmt.Errorf("failed to send request: timed out after %s", h.timeout)
+		}
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if stream {
+		timer.Stop()
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: func() {
+		timer.Stop()
+		cancel()
+	}}
+	return resp, nil
+}
+
+// backoff returns the jittered wait before retry number attempt+1: a random
+// duration between half and all of the exponential step
+func (h *httpClient) backoff(attempt int) time.Duration {
+	step := h.initialBackoff << attempt
+	if step > h.maxBackoff || step <= 0 {
+		step = h.maxBackoff
+	}
+	half := step / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case 429, 500, 502, 503, 504, 529:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads retry-after-ms (OpenAI) or retry-after in seconds or
+// as an HTTP date; zero means the server didn't say
+func parseRetryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	value := header.Get("retry-after")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// cancelOnClose releases an attempt's context when the body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
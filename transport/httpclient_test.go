@@ -0,0 +1,175 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedReply is one response from scriptedServer
+type scriptedReply struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+// scriptedServer answers each request with the next reply in the script and
+// repeats the last one once the script runs out
+func scriptedServer(t *testing.T, script ...scriptedReply) (*httptest.Server, func() int) {
+	t.Helper()
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reply := script[min(calls, len(script)-1)]
+		calls++
+		mu.Unlock()
+
+		io.Copy(io.Discard, r.Body)
+		for k, v := range reply.header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(reply.status)
+		io.WriteString(w, reply.body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+// testHTTPClient retries quickly so tests don't wait out the real backoff
+func testHTTPClient(maxRetries int) *httpClient {
+	h := newHTTPClient("test", maxRetries, 5*time.Second)
+	h.initialBackoff = time.Millisecond
+	h.maxBackoff = 4 * time.Millisecond
+	return h
+}
+
+func TestPostRetriesRateLimitsAndServerErrors(t *testing.T) {
+	srv, calls := scriptedServer(t,
+		scriptedReply{status: 429, body: "rate_limit"},
+		scriptedReply{status: 500, body: "internal"},
+		scriptedReply{status: 503, body: "overloaded"},
+		scriptedReply{status: 200, body: "ok"},
+	)
+
+	resp, err := testHTTPClient(3).post(context.Background(), srv.URL, http.Header{}, []byte("{}"), false)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if string(data) != "ok" {
+		t.Fatalf("body = %q, want ok", data)
+	}
+	if got := calls(); got != 4 {
+		t.Fatalf("server saw %d requests, want 4", got)
+	}
+}
+
+func TestPostGivesUpAfterMaxRetries(t *testing.T) {
+	srv, calls := scriptedServer(t, scriptedReply{status: 502, body: "bad gateway"})
+
+	_, err := testHTTPClient(2).post(context.Background(), srv.URL, http.Header{}, []byte("{}"), false)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 502 {
+		t.Fatalf("err = %v, want a 502 APIError", err)
+	}
+	if got := calls(); got != 3 {
+		t.Fatalf("server saw %d requests, want 3", got)
+	}
+}
+
+func TestPostDoesNotRetryClientErrors(t *testing.T) {
+	srv, calls := scriptedServer(t, scriptedReply{status: 400, body: "bad request"})
+
+	_, err := testHTTPClient(3).post(context.Background(), srv.URL, http.Header{}, []byte("{}"), false)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 || apiErr.Body != "bad request" {
+		t.Fatalf("err = %v, want a 400 APIError", err)
+	}
+	if got := calls(); got != 1 {
+		t.Fatalf("server saw %d requests, want 1", got)
+	}
+}
+
+func TestPostHonorsRetryAfter(t *testing.T) {
+	srv, calls := scriptedServer(t,
+		scriptedReply{status: 429, header: map[string]string{"retry-after-ms": "5"}},
+		scriptedReply{status: 200, body: "ok"},
+	)
+
+	// The backoff alone would outlast the context, so only the server's
+	// Retry-After can get the second attempt through
+	h := testHTTPClient(1)
+	h.initialBackoff = time.Minute
+	h.maxBackoff = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resp, err := h.post(ctx, srv.URL, http.Header{}, []byte("{}"), false)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	if got := calls(); got != 2 {
+		t.Fatalf("server saw %d requests, want 2", got)
+	}
+}
+
+func TestPostReturnsLongRetryAfter(t *testing.T) {
+	srv, calls := scriptedServer(t, scriptedReply{status: 429, header: map[string]string{"retry-after": "120"}})
+
+	_, err := testHTTPClient(3).post(context.Background(), srv.URL, http.Header{}, []byte("{}"), false)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 {
+		t.Fatalf("err = %v, want a 429 APIError", err)
+	}
+	if got := calls(); got != 1 {
+		t.Fatalf("server saw %d requests, want 1", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"none", http.Header{}, 0},
+		{"milliseconds", http.Header{"Retry-After-Ms": {"250"}}, 250 * time.Millisecond},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{"past date", http.Header{"Retry-After": {"Mon, 02 Jan 2006 15:04:05 GMT"}}, 0},
+		{"garbage", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.header); got != tt.want {
+				t.Fatalf("parseRetryAfter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffStaysWithinBounds(t *testing.T) {
+	h := &httpClient{initialBackoff: time.Second, maxBackoff: 8 * time.Second}
+	for attempt := 0; attempt < 70; attempt++ {
+		step := min(time.Second<<min(attempt, 10), 8*time.Second)
+		if wait := h.backoff(attempt); wait < step/2 || wait > step {
+			t.Fatalf("backoff(%d) = %s, want between %s and %s", attempt, wait, step/2, step)
+		}
+	}
+}
@@ -2,17 +2,17 @@
 package transport
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	agent "github.com/AnthonyL103/GOMCP/Agent"
 	"github.com/AnthonyL103/GOMCP/chat"
-	"github.com/AnthonyL103/GOMCP/infrageneration"
 	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
-	"github.com/AnthonyL103/GOMCP/servergeneration"
 )
 
 type OpenAIProvider struct {
@@ -20,193 +20,123 @@ type OpenAIProvider struct {
 	Model       string
 	Temperature float32
 	MaxTokens   int
-	// Optional callback fired immediately after each tool cycle completes,
-	// before the next LLM call. Used by the HTTP server to broadcast over WS.
-	OnToolCall func(msg chat.Message)
+	// MaxRetries and Timeout tune the HTTP layer; zero uses the agent defaults
+	MaxRetries int
+	Timeout    time.Duration
+	// BaseURL is the API root, without the /chat/completions path
+	BaseURL string
+	// Optional tool progress callbacks. Used by the HTTP server to broadcast over WS.
+	llmprotocol.Hooks
+
+	name string
 }
 
+const openAIBaseURL = "https://api.openai.com/v1"
+
 func NewOpenAIProvider(config *agent.LLMConfig) *OpenAIProvider {
 	return &OpenAIProvider{
 		APIKey:      config.APIKey,
 		Model:       config.Model,
 		Temperature: config.Temperature,
 		MaxTokens:   config.MaxTokens,
+		MaxRetries:  config.MaxRetries,
+		Timeout:     config.Timeout,
+		BaseURL:     baseURL(config, openAIBaseURL),
+		name:        agent.ProviderOpenAI,
 	}
 }
 
-func (p *OpenAIProvider) GetProviderName() string {
-	return "openai"
+// NewOpenAICompatibleProvider talks the Chat Completions API to a
+// self-hosted endpoint such as Ollama or vLLM. The API key may be empty.
+func NewOpenAICompatibleProvider(config *agent.LLMConfig) *OpenAIProvider {
+	p := NewOpenAIProvider(config)
+	p.name = agent.ProviderOpenAICompatible
+	return p
 }
 
-func (p *OpenAIProvider) SendRequest(c *chat.Chat, ag *agent.Agent, userMessage string) error {
-	// Add user message to chat
-	c.AddUserMessage(userMessage)
-
-	// Extract agent instructions
-	agentInstructions := llmprotocol.GetAgentInstructions(ag)
+// SetToolCallbacks installs the tool progress callbacks
+func (p *OpenAIProvider) SetToolCallbacks(onStart func(call chat.ToolCall), onResult func(call chat.ToolCall, result chat.ToolResult)) {
+	p.OnToolStart = onStart
+	p.OnToolResult = onResult
+}
 
-	// Extract and format tools
-	availableTools := llmprotocol.ExtractTools(ag)
-	formattedTools := p.buildTools(availableTools, ag)
+func (p *OpenAIProvider) GetProviderName() string {
+	if p.name == "" {
+		return agent.ProviderOpenAI
+	}
+	return p.name
+}
 
-	// Convert chat history to OpenAI format
-	messages := p.buildMessages(c, agentInstructions)
+func (p *OpenAIProvider) SendRequest(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string) error {
+	return llmprotocol.RunTurn(ctx, p, c, ag, userMessage, p.Hooks, nil)
+}
 
-	// Create OpenAI API request
-	requestBody := map[string]interface{}{
-		"model":       p.Model,
-		"messages":    messages,
-		"temperature": p.Temperature,
-		"max_tokens":  p.MaxTokens,
-	}
+func (p *OpenAIProvider) SendRequestStream(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string, onEvent StreamHandler) error {
+	return llmprotocol.RunTurn(ctx, p, c, ag, userMessage, p.Hooks, onEvent)
+}
 
-	if len(formattedTools) > 0 {
-		requestBody["tools"] = formattedTools
-	}
+// Complete sends one Chat Completions request; a nil req.OnEvent uses the
+// non-streaming API
+func (p *OpenAIProvider) Complete(ctx context.Context, req *llmprotocol.Request) (*llmprotocol.Response, error) {
+	model := req.ModelOr(p.Model)
+	requestBody := p.BuildRequestBody(req)
 
-	// Send request
-	response, err := p.sendHTTPRequest(requestBody)
+	response, err := p.sendHTTPRequest(ctx, requestBody, req.OnEvent)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Parse response - returns tool calls and response text
-	responseText, toolCalls, err := p.parseResponse(response)
+	blocks, finishReason, err := p.parseResponse(response)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Handle tool calls - loop while model wants to use tools
-	toolsProcessed := false
-	for len(toolCalls) > 0 {
-		toolsProcessed = true
-
-		// Build ONE assistant message with ALL tool calls
-		toolCallsArray := []map[string]interface{}{}
-		for toolCallID, toolCall := range toolCalls {
-			toolCallMap := toolCall.(map[string]interface{})
-			toolCallsArray = append(toolCallsArray, map[string]interface{}{
-				"id":   toolCallID,
-				"type": "function",
-				"function": map[string]interface{}{
-					"name":      toolCallMap["name"].(string),
-					"arguments": p.jsonString(toolCallMap["arguments"].(map[string]interface{})),
-				},
-			})
-		}
-
-		// Add single assistant message with all tool calls
-		messages = append(messages, map[string]interface{}{
-			"role":       "assistant",
-			"tool_calls": toolCallsArray,
-		})
-
-		// Execute and add tool results
-		for toolCallID, toolCall := range toolCalls {
-			toolCallMap := toolCall.(map[string]interface{})
-			currentToolName := toolCallMap["name"].(string)
-			currentToolArgs := toolCallMap["arguments"].(map[string]interface{})
-
-			// Look up the server that owns this tool
-			toolInfo, exists := availableTools[currentToolName]
-			if !exists {
-				if !isServerGenerationToolOpenAI(currentToolName) && !isInfraGenerationToolOpenAI(currentToolName) {
-					return fmt.Errorf("tool %s not found", currentToolName)
-				}
-				toolInfo = llmprotocol.ToolInfo{ServerID: "infrastructure_generation", Handler: currentToolName}
-			}
-
-			if isServerGenerationToolOpenAI(currentToolName) && !ag.ServerGeneration {
-				return fmt.Errorf("tool %s not available; enable server generation in config", currentToolName)
-			}
-
-			if isInfraGenerationToolOpenAI(currentToolName) && !ag.InfraGeneration {
-				return fmt.Errorf("tool %s not available; enable infra generation in config", currentToolName)
-			}
-
-			// Execute the tool
-			toolResult, isError := llmprotocol.ExecuteTool(ag, &chat.ToolCall{
-				ServerID:   toolInfo.ServerID,
-				ToolID:     currentToolName,
-				Handler:    toolInfo.Handler,
-				Parameters: currentToolArgs,
-			})
-
-			// Build the completed tool cycle message
-			toolMsg := chat.Message{
-				Role: "assistant",
-				ToolCall: &chat.ToolCall{
-					ServerID:   toolInfo.ServerID,
-					ToolID:     currentToolName,
-					Handler:    toolInfo.Handler,
-					Parameters: currentToolArgs,
-					Reasoning:  "",
-					ToolUseID:  toolCallID,
-				},
-				ToolResult: &chat.ToolResult{
-					ServerID:  toolInfo.ServerID,
-					ToolID:    currentToolName,
-					Content:   toolResult,
-					IsError:   isError,
-					ToolUseID: toolCallID,
-				},
-			}
-
-			if p.OnToolCall != nil {
-				p.OnToolCall(toolMsg)
-			}
-
-			// Add tool result message
-			messages = append(messages, map[string]interface{}{
-				"role":         "tool",
-				"tool_call_id": toolCallID,
-				"content":      toolResult,
-			})
-
-			// Save this tool cycle to chat history
-			c.AddAssistantMessage("", toolMsg.ToolCall, toolMsg.ToolResult)
-		}
-
-		// Send follow-up request with ALL tool results
-		requestBody["messages"] = messages
-		response, err = p.sendHTTPRequest(requestBody)
-		if err != nil {
-			return err
-		}
-
-		// Update variables for next iteration
-		responseText, toolCalls, err = p.parseResponse(response)
-		if err != nil {
-			return err
-		}
+	return &llmprotocol.Response{Blocks: blocks, StopReason: openAIStopReason(finishReason), Usage: p.parseUsage(response, model)}, nil
+}
 
-		// Loop continues if more tool calls exist
+// BuildRequestBody encodes req as a Chat Completions request body
+func (p *OpenAIProvider) BuildRequestBody(req *llmprotocol.Request) map[string]interface{} {
+	requestBody := map[string]interface{}{
+		"model":       req.ModelOr(p.Model),
+		"temperature": p.Temperature,
+		"max_tokens":  p.MaxTokens,
+		"messages":    p.buildMessages(req.Messages, req.System),
 	}
 
-	// Save final text response (whether tools were used or not)
-	if responseText != "" {
-		if toolsProcessed {
-			// Update last message with final response text
-			messages := c.GetMessages()
-			if len(messages) > 0 {
-				lastMsg := &messages[len(messages)-1]
-				lastMsg.Content = responseText
-			}
-		} else {
-			c.AddAssistantMessage(responseText, nil, nil)
-		}
+	if len(req.Tools) > 0 {
+		requestBody["tools"] = p.buildTools(req.Tools)
 	}
+	return requestBody
+}
 
-	return nil
+// parseUsage reads the usage block. prompt_tokens includes cached input, so
+// those are moved to CacheReadTokens.
+func (p *OpenAIProvider) parseUsage(response map[string]interface{}, model string) *chat.Usage {
+	usage, _ := response["usage"].(map[string]interface{})
+	details, _ := usage["prompt_tokens_details"].(map[string]interface{})
+	cached := intField(details, "cached_tokens")
+
+	return &chat.Usage{
+		Model:           model,
+		InputTokens:     intField(usage, "prompt_tokens") - cached,
+		OutputTokens:    intField(usage, "completion_tokens"),
+		CacheReadTokens: cached,
+	}
 }
 
-type toolCallInfo struct {
-	ID        string
-	Name      string
-	Arguments map[string]interface{}
+// openAIStopReason maps a finish_reason to the neutral stop reasons
+func openAIStopReason(finishReason string) string {
+	switch finishReason {
+	case "tool_calls":
+		return llmprotocol.StopToolUse
+	case "length":
+		return llmprotocol.StopMaxTokens
+	default:
+		return llmprotocol.StopEndTurn
+	}
 }
 
-func (p *OpenAIProvider) buildMessages(c *chat.Chat, systemMessage string) []map[string]interface{} {
+func (p *OpenAIProvider) buildMessages(history []chat.Message, systemMessage string) []map[string]interface{} {
 	messages := []map[string]interface{}{}
 
 	// Add system message first
@@ -217,51 +147,47 @@ func (p *OpenAIProvider) buildMessages(c *chat.Chat, systemMessage string) []map
 		})
 	}
 
-	for _, msg := range c.GetMessages() {
+	for _, msg := range history {
 		switch msg.Role {
-		case "user":
+		case chat.RoleUser:
 			messages = append(messages, map[string]interface{}{
 				"role":    "user",
-				"content": msg.Content,
+				"content": p.buildUserContent(msg),
 			})
 
-		case "assistant":
-			// If message has both ToolCall and ToolResult, expand into tool call format
-			if msg.ToolCall != nil && msg.ToolResult != nil {
-				// 1. Assistant message with tool_calls
-				messages = append(messages, map[string]interface{}{
-					"role": "assistant",
-					"tool_calls": []map[string]interface{}{
-						{
-							"id":   msg.ToolCall.ToolUseID,
-							"type": "function",
-							"function": map[string]interface{}{
-								"name":      msg.ToolCall.ToolID,
-								"arguments": p.jsonString(msg.ToolCall.Parameters),
-							},
-						},
+		case chat.RoleAssistant:
+			// Text becomes content, tool calls go in tool_calls; thinking is not replayed
+			assistant := map[string]interface{}{"role": "assistant"}
+			if text := msg.Text(); text != "" {
+				assistant["content"] = text
+			}
+
+			toolCalls := []map[string]interface{}{}
+			for _, call := range msg.ToolCalls() {
+				toolCalls = append(toolCalls, map[string]interface{}{
+					"id":   call.ToolUseID,
+					"type": "function",
+					"function": map[string]interface{}{
+						"name":      call.ToolID,
+						"arguments": p.jsonString(call.Parameters),
 					},
 				})
+			}
+			if len(toolCalls) > 0 {
+				assistant["tool_calls"] = toolCalls
+			}
 
-				// 2. Tool message with result
-				messages = append(messages, map[string]interface{}{
-					"role":         "tool",
-					"tool_call_id": msg.ToolResult.ToolUseID,
-					"content":      msg.ToolResult.Content,
-				})
+			if len(assistant) > 1 {
+				messages = append(messages, assistant)
+			}
 
-				// 3. Assistant message with final text response if present
-				if msg.Content != "" {
-					messages = append(messages, map[string]interface{}{
-						"role":    "assistant",
-						"content": msg.Content,
-					})
-				}
-			} else if msg.Content != "" {
-				// Regular assistant message
+		case chat.RoleTool:
+			// One tool message per result
+			for _, result := range msg.ToolResults() {
 				messages = append(messages, map[string]interface{}{
-					"role":    "assistant",
-					"content": msg.Content,
+					"role":         "tool",
+					"tool_call_id": result.ToolUseID,
+					"content":      result.Content,
 				})
 			}
 		}
@@ -270,63 +196,104 @@ func (p *OpenAIProvider) buildMessages(c *chat.Chat, systemMessage string) []map
 	return messages
 }
 
-func (p *OpenAIProvider) buildTools(availableTools map[string]llmprotocol.ToolInfo, ag *agent.Agent) []map[string]interface{} {
-	tools := []map[string]interface{}{}
+// buildUserContent returns plain text for text-only messages, or content
+// parts when the message carries images
+func (p *OpenAIProvider) buildUserContent(msg chat.Message) interface{} {
+	hasImage := false
+	for _, block := range msg.Blocks {
+		if block.Type == chat.BlockImage && block.Image != nil {
+			hasImage = true
+		}
+	}
+	if !hasImage {
+		return msg.Text()
+	}
 
-	for toolID, toolInfo := range availableTools {
-		formatschema := toolInfo.Schema
-		formatschema["type"] = "object"
+	parts := []map[string]interface{}{}
+	for _, block := range msg.Blocks {
+		switch {
+		case block.Type == chat.BlockText && block.Text != "":
+			parts = append(parts, map[string]interface{}{
+				"type": "text",
+				"text": block.Text,
+			})
+		case block.Type == chat.BlockImage && block.Image != nil:
+			url := block.Image.URL
+			if url == "" {
+				url = fmt.Sprintf("data:%s;base64,%s", block.Image.MediaType, block.Image.Data)
+			}
+			parts = append(parts, map[string]interface{}{
+				"type":      "image_url",
+				"image_url": map[string]interface{}{"url": url},
+			})
+		}
+	}
+	return parts
+}
 
+func (p *OpenAIProvider) buildTools(specs []llmprotocol.ToolSpec) []map[string]interface{} {
+	tools := make([]map[string]interface{}, 0, len(specs))
+	for _, spec := range specs {
 		tools = append(tools, map[string]interface{}{
 			"type": "function",
 			"function": map[string]interface{}{
-				"name":        toolID,
-				"description": toolInfo.Description,
-				"parameters":  formatschema,
+				"name":        spec.Name,
+				"description": spec.Description,
+				"parameters":  spec.InputSchema,
 			},
 		})
 	}
-
-	if ag.ServerGeneration {
-		tools = append(tools, servergeneration.OpenAIToolSpecs()...)
-	}
-	if ag.InfraGeneration {
-		tools = append(tools, infrageneration.OpenAIToolSpecs()...)
-	}
-
 	return tools
 }
 
-func (p *OpenAIProvider) sendHTTPRequest(requestBody map[string]interface{}) (map[string]interface{}, error) {
+// sendHTTPRequest posts to the Chat Completions API. With a non-nil onEvent
+// the response is streamed and reassembled into the same shape as a regular one.
+func (p *OpenAIProvider) sendHTTPRequest(ctx context.Context, requestBody map[string]interface{}, onEvent StreamHandler) (map[string]interface{}, error) {
+	if onEvent != nil {
+		streamBody := make(map[string]interface{}, len(requestBody)+1)
+		for k, v := range requestBody {
+			streamBody[k] = v
+		}
+		streamBody["stream"] = true
+		// Usage is only sent in a final chunk when asked for
+		streamBody["stream_options"] = map[string]interface{}{"include_usage": true}
+		requestBody = streamBody
+	}
+
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	endpoint := p.BaseURL
+	if endpoint == "" {
+		endpoint = openAIBaseURL
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+	if onEvent != nil {
+		header.Set("Accept", "text/event-stream")
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := newHTTPClient(p.GetProviderName(), p.MaxRetries, p.Timeout).post(ctx, endpoint+"/chat/completions", header, jsonData, onEvent != nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if onEvent != nil {
+		return p.readStream(resp.Body, onEvent)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
 	var response map[string]interface{}
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
@@ -335,43 +302,159 @@ func (p *OpenAIProvider) sendHTTPRequest(requestBody map[string]interface{}) (ma
 	return response, nil
 }
 
-// parseResponse extracts relevant data from OpenAI response
-// Returns: (responseText, toolCalls array, error)
-func (p *OpenAIProvider) parseResponse(response map[string]interface{}) (string, map[string]interface{}, error) {
+// streamedToolCall accumulates one tool call across stream chunks
+type streamedToolCall struct {
+	ID        string
+	Name      string
+	Arguments strings.Builder
+}
+
+// readStream consumes Chat Completions chunks, forwarding deltas to onEvent,
+// and returns a response shaped like a non-streamed completion
+func (p *OpenAIProvider) readStream(body io.Reader, onEvent StreamHandler) (map[string]interface{}, error) {
+	var text strings.Builder
+	var toolCalls []*streamedToolCall
+	finishReason := ""
+	response := map[string]interface{}{}
+
+	err := readSSE(body, func(_, data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+
+		var chunk map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+
+		if apiErr, ok := chunk["error"].(map[string]interface{}); ok {
+			return &APIError{Provider: p.GetProviderName(), Body: fmt.Sprintf("%v: %v", apiErr["type"], apiErr["message"])}
+		}
+
+		for _, key := range []string{"id", "model", "usage"} {
+			if value, ok := chunk[key]; ok && value != nil {
+				response[key] = value
+			}
+		}
+
+		choices, _ := chunk["choices"].([]interface{})
+		if len(choices) == 0 {
+			return nil
+		}
+		choice, _ := choices[0].(map[string]interface{})
+		if reason, ok := choice["finish_reason"].(string); ok {
+			finishReason = reason
+		}
+
+		delta, _ := choice["delta"].(map[string]interface{})
+		if delta == nil {
+			return nil
+		}
+
+		if content, ok := delta["content"].(string); ok && content != "" {
+			text.WriteString(content)
+			onEvent(StreamEvent{Type: StreamText, Text: content})
+		}
+
+		deltaCalls, _ := delta["tool_calls"].([]interface{})
+		for _, raw := range deltaCalls {
+			deltaCall, _ := raw.(map[string]interface{})
+			if deltaCall == nil {
+				continue
+			}
+			index := streamIndex(deltaCall)
+			for len(toolCalls) <= index {
+				toolCalls = append(toolCalls, &streamedToolCall{})
+			}
+			call := toolCalls[index]
+
+			if id, ok := deltaCall["id"].(string); ok && id != "" {
+				call.ID = id
+			}
+			function, _ := deltaCall["function"].(map[string]interface{})
+			if name, ok := function["name"].(string); ok && name != "" {
+				call.Name = name
+			}
+			if fragment, ok := function["arguments"].(string); ok && fragment != "" {
+				call.Arguments.WriteString(fragment)
+				onEvent(StreamEvent{Type: StreamToolInput, ToolUseID: call.ID, ToolName: call.Name, PartialJSON: fragment})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	message := map[string]interface{}{
+		"role":    "assistant",
+		"content": text.String(),
+	}
+	if len(toolCalls) > 0 {
+		calls := make([]interface{}, 0, len(toolCalls))
+		for _, call := range toolCalls {
+			calls = append(calls, map[string]interface{}{
+				"id":   call.ID,
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":      call.Name,
+					"arguments": call.Arguments.String(),
+				},
+			})
+		}
+		message["tool_calls"] = calls
+	}
+
+	response["choices"] = []interface{}{
+		map[string]interface{}{
+			"index":         float64(0),
+			"message":       message,
+			"finish_reason": finishReason,
+		},
+	}
+
+	return response, nil
+}
+
+// parseResponse converts the first choice to chat blocks: the text, if any,
+// followed by the tool calls in the order the model listed them. It also
+// returns the finish reason.
+func (p *OpenAIProvider) parseResponse(response map[string]interface{}) ([]chat.ContentBlock, string, error) {
 	choices, ok := response["choices"].([]interface{})
 	if !ok || len(choices) == 0 {
-		return "", nil, fmt.Errorf("no choices in response")
+		return nil, "", fmt.Errorf("no choices in response")
 	}
 
 	choice := choices[0].(map[string]interface{})
+	finishReason, _ := choice["finish_reason"].(string)
 	message := choice["message"].(map[string]interface{})
-	responseText := ""
+	blocks := []chat.ContentBlock{}
 
-	if content, ok := message["content"].(string); ok {
-		responseText = content
+	if content, ok := message["content"].(string); ok && content != "" {
+		blocks = append(blocks, chat.TextBlock(content))
 	}
 
-	var toolCalls map[string]interface{}
 	if toolCallsRaw, ok := message["tool_calls"].([]interface{}); ok {
-		toolCalls = make(map[string]interface{})
 		for _, block := range toolCallsRaw {
 			blockMap := block.(map[string]interface{})
 			function := blockMap["function"].(map[string]interface{})
 
 			// Parse arguments JSON string
-			var args map[string]interface{}
+			args := map[string]interface{}{}
 			if argsStr, ok := function["arguments"].(string); ok {
 				json.Unmarshal([]byte(argsStr), &args)
 			}
 
-			toolCalls[blockMap["id"].(string)] = map[string]interface{}{
-				"name":      function["name"].(string),
-				"arguments": args,
-			}
+			blocks = append(blocks, chat.ToolCallBlock(chat.ToolCall{
+				ToolID:     function["name"].(string),
+				Parameters: args,
+				ToolUseID:  blockMap["id"].(string),
+			}))
 		}
 	}
 
-	return responseText, toolCalls, nil
+	return blocks, finishReason, nil
 }
 
 // jsonString converts a map to JSON string
@@ -379,11 +462,3 @@ func (p *OpenAIProvider) jsonString(data map[string]interface{}) string {
 	bytes, _ := json.Marshal(data)
 	return string(bytes)
 }
-
-func isServerGenerationToolOpenAI(name string) bool {
-	return servergeneration.IsServerGenerationTool(name)
-}
-
-func isInfraGenerationToolOpenAI(name string) bool {
-	return infrageneration.IsInfraGenerationTool(name)
-}
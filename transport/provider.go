@@ -1,16 +1,82 @@
 package transport
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	agent "github.com/AnthonyL103/GOMCP/Agent"
 	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/modelcatalog"
 )
 
 // Provider handles LLM API communication
 type Provider interface {
 	// SendRequest sends a message and returns the assistant's response
 	// It handles the full cycle: LLM call → tool execution → final response
-	SendRequest(chat *chat.Chat, agent *agent.Agent, userMessage string) error
+	// Cancelling ctx aborts the in-flight LLM call or tool and returns its error
+	SendRequest(ctx context.Context, chat *chat.Chat, agent *agent.Agent, userMessage string) error
+
+	// SendRequestStream is SendRequest over a streamed API response. Text
+	// deltas and partial tool arguments are passed to onEvent as they arrive;
+	// the chat history ends up the same as with SendRequest.
+	SendRequestStream(ctx context.Context, chat *chat.Chat, agent *agent.Agent, userMessage string, onEvent StreamHandler) error
 
-	// GetProviderName returns the provider name (e.g., "openai", "anthropic")
+	// GetProviderName returns the provider name (e.g., "openai", "anthropic", "gemini")
 	GetProviderName() string
 }
+
+// ToolCallbackSetter is implemented by providers that report tool progress
+type ToolCallbackSetter interface {
+	// SetToolCallbacks installs callbacks fired before each tool runs and
+	// after each tool call completes. Either may be nil.
+	SetToolCallbacks(onStart func(call chat.ToolCall), onResult func(call chat.ToolCall, result chat.ToolResult))
+}
+
+// NewProvider creates the configured provider, or detects it from the model
+// name when llmConfig.Provider is empty. A config with fallbacks gets a
+// FallbackProvider. Each call returns a fresh provider so callers can attach
+// their own callbacks.
+func NewProvider(llmConfig *agent.LLMConfig) (Provider, error) {
+	if len(llmConfig.Fallbacks) > 0 {
+		return NewFallbackProvider(llmConfig)
+	}
+
+	switch llmConfig.Provider {
+	case agent.ProviderAnthropic:
+		return NewAnthropicProvider(llmConfig), nil
+	case agent.ProviderOpenAI:
+		return NewOpenAIProvider(llmConfig), nil
+	case agent.ProviderGemini:
+		return NewGeminiProvider(llmConfig), nil
+	case agent.ProviderOpenAICompatible:
+		return NewOpenAICompatibleProvider(llmConfig), nil
+	case "":
+		// Detect the provider from the model below
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s", llmConfig.Provider)
+	}
+
+	model, ok := modelcatalog.Lookup(llmConfig.Model)
+	if !ok {
+		return nil, fmt.Errorf("unsupported model: %s", llmConfig.Model)
+	}
+
+	detected := *llmConfig
+	detected.Provider = model.Provider
+	return NewProvider(&detected)
+}
+
+// baseURL returns the configured API root, or fallback when none is set
+func baseURL(llmConfig *agent.LLMConfig, fallback string) string {
+	if llmConfig.BaseURL != "" {
+		return strings.TrimRight(llmConfig.BaseURL, "/")
+	}
+	return fallback
+}
+
+// intField reads a JSON number from a decoded object, 0 when missing
+func intField(object map[string]interface{}, key string) int {
+	value, _ := object[key].(float64)
+	return int(value)
+}
@@ -0,0 +1,40 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
+)
+
+// Router picks the agent for each user message using the roster's router
+// agent. It is safe to share between sessions.
+type Router struct {
+	roster  *agent.Roster
+	adapter llmprotocol.Adapter
+}
+
+// NewRouter creates the router agent's provider. The roster must have a router.
+func NewRouter(roster *agent.Roster) (*Router, error) {
+	if roster.Router == nil {
+		return nil, fmt.Errorf("no router configured")
+	}
+
+	provider, err := NewProvider(roster.Router.LLMConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	adapter, ok := provider.(llmprotocol.Adapter)
+	if !ok {
+		return nil, fmt.Errorf("provider %s cannot route messages", provider.GetProviderName())
+	}
+	return &Router{roster: roster, adapter: adapter}, nil
+}
+
+// Route returns the agent that should answer message in chat c
+func (r *Router) Route(ctx context.Context, c *chat.Chat, message string) (*agent.Agent, error) {
+	return llmprotocol.RouteMessage(ctx, r.adapter, r.roster, c, message)
+}
@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AnthonyL103/GOMCP/protocol/llmprotocol"
+)
+
+// Stream types live in llmprotocol so the shared agent loop can use them
+const (
+	StreamText      = llmprotocol.StreamText
+	StreamToolInput = llmprotocol.StreamToolInput
+)
+
+type StreamEvent = llmprotocol.StreamEvent
+
+type StreamHandler = llmprotocol.StreamHandler
+
+const maxSSELine = 10 * 1024 * 1024
+
+// readSSE reads a server-sent event stream and calls fn for every event
+// with its event name (may be empty) and joined data lines
+func readSSE(body io.Reader, fn func(event, data string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxSSELine)
+
+	eventName := ""
+	var data []string
+
+	dispatch := func() error {
+		if len(data) == 0 {
+			eventName = ""
+			return nil
+		}
+		err := fn(eventName, strings.Join(data, "\n"))
+		eventName = ""
+		data = data[:0]
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// comment / keep-alive
+		case strings.HasPrefix(line, "event:"):
+			eventName = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	// Last event without a trailing blank line
+	return dispatch()
+}
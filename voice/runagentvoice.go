@@ -1,13 +1,21 @@
 package voicechat
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	agent "github.com/AnthonyL103/GOMCP/Agent"
 	"github.com/AnthonyL103/GOMCP/chat"
 	"github.com/AnthonyL103/GOMCP/transport"
 )
 
+// finalizeCheckInterval is how often Start checks whether the user has
+// finished speaking
+const finalizeCheckInterval = 100 * time.Millisecond
+
 type VoiceChatParser struct {
 	chatSession *chat.Chat
 	agent       *agent.Agent
@@ -16,6 +24,11 @@ type VoiceChatParser struct {
 	voiceState   *VoiceSessionState
 	runtimeState *RuntimeExecutionState
 	policy       InterruptPolicy
+
+	// transcripts carries chunks from Hear to Start
+	transcripts chan string
+	// turnDone is closed when the latest voice turn ends; nil before the first
+	turnDone chan struct{}
 }
 
 func NewVoiceChatParser(chatSession *chat.Chat, ag *agent.Agent, provider transport.Provider) *VoiceChatParser {
@@ -27,6 +40,7 @@ func NewVoiceChatParser(chatSession *chat.Chat, ag *agent.Agent, provider transp
 		voiceState:   NewVoiceSessionState(policy),
 		runtimeState: NewRuntimeExecutionState(),
 		policy:       policy,
+		transcripts:  make(chan string, 64),
 	}
 }
 
@@ -38,19 +52,85 @@ func GetLastMessage(c *chat.Chat) *chat.Message {
 	return &messages[len(messages)-1]
 }
 
+// Hear passes one chunk of transcribed speech to Start
+func (p *VoiceChatParser) Hear(chunk string) {
+	p.transcripts <- chunk
+}
+
+// Interrupt applies the interrupt policy to the running job, e.g. when the
+// user starts speaking over the agent
+func (p *VoiceChatParser) Interrupt(reason string) (canceled bool, paused bool) {
+	return p.runtimeState.InterruptForVoice(p.policy, reason, time.Now())
+}
+
+// Start collects transcript chunks into utterances and answers each one in a
+// cancellable voice turn. Speech heard while the agent is answering
+// interrupts the turn under the interrupt policy. Start returns when the
+// user says "exit" or "quit".
 func (p *VoiceChatParser) Start() {
-	// This is where you'd integrate with the actual voice recognition system
+	// This is where you'd integrate with the actual voice recognition system:
+	// it calls Hear with every chunk it transcribes
+
+	ticker := time.NewTicker(finalizeCheckInterval)
+	defer ticker.Stop()
 
 	for {
+		select {
+		case chunk := <-p.transcripts:
+			p.hear(chunk)
 
-		lastMsg := GetLastMessage(p.chatSession)
-		if lastMsg != nil && lastMsg.Role == "user" {
-			// Simulate receiving a voice input as text for now
+		case now := <-ticker.C:
+			if !p.voiceState.ShouldFinalize(now) && !p.voiceState.ShouldForceFinalize(now) {
+				continue
+			}
+			utterance := p.voiceState.FinalizeUtterance()
+			if utterance == "" {
+				continue
+			}
+			if utterance == "exit" || utterance == "quit" {
+				p.Interrupt("exit")
+				log.Println("Shutting down...")
+				return
+			}
+			p.startTurn(utterance)
 		}
+	}
+}
 
-		if lastMsg.Content == "exit" || lastMsg.Content == "quit" {
-			log.Println("Shutting down...")
-			break
+// hear adds chunk to the utterance in progress, first barging in on the
+// agent's reply if one is running
+func (p *VoiceChatParser) hear(chunk string) {
+	if p.runtimeState.Snapshot().CurrentType == JobLLMResponse {
+		if canceled, _ := p.Interrupt("user spoke"); canceled {
+			log.Println("Interrupted the agent's reply")
 		}
 	}
+	p.voiceState.AppendTranscript(chunk, time.Now())
+}
+
+// startTurn answers utterance in the background. The turn's context is
+// registered with the runtime state before it starts, so speech heard from
+// then on can cancel it. An interrupted turn is waited for first, so turns
+// never overlap in the chat.
+func (p *VoiceChatParser) startTurn(utterance string) {
+	if p.turnDone != nil {
+		<-p.turnDone
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobID := fmt.Sprintf("llm-%d", time.Now().UnixNano())
+	p.runtimeState.BeginJob(jobID, JobLLMResponse, "llm_request", cancel)
+
+	done := make(chan struct{})
+	p.turnDone = done
+	go func() {
+		defer close(done)
+		defer cancel()
+		defer p.runtimeState.CompleteJob(jobID)
+
+		err := p.provider.SendRequest(ctx, p.chatSession, p.agent, utterance)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("Voice turn failed: %v", err)
+		}
+	}()
 }
@@ -0,0 +1,86 @@
+package voicechat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	agent "github.com/AnthonyL103/GOMCP/Agent"
+	"github.com/AnthonyL103/GOMCP/chat"
+	"github.com/AnthonyL103/GOMCP/registry"
+	"github.com/AnthonyL103/GOMCP/transport"
+)
+
+// blockingProvider answers only once its context is cancelled, reporting
+// each request on started and each cancellation on canceled
+type blockingProvider struct {
+	started  chan string
+	canceled chan string
+}
+
+func (p *blockingProvider) SendRequest(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string) error {
+	p.started <- userMessage
+	<-ctx.Done()
+	p.canceled <- userMessage
+	return ctx.Err()
+}
+
+func (p *blockingProvider) SendRequestStream(ctx context.Context, c *chat.Chat, ag *agent.Agent, userMessage string, onEvent transport.StreamHandler) error {
+	return p.SendRequest(ctx, c, ag, userMessage)
+}
+
+func (p *blockingProvider) GetProviderName() string { return "test" }
+
+func TestBargeInCancelsVoiceTurn(t *testing.T) {
+	ag := agent.NewAgent("tester", "Test agent", registry.NewRegistry(), &agent.LLMConfig{
+		APIKey:      "test",
+		Model:       "gpt-4o",
+		Temperature: 0.5,
+		MaxTokens:   100,
+	}, false, true, false)
+	provider := &blockingProvider{started: make(chan string, 1), canceled: make(chan string, 1)}
+
+	p := NewVoiceChatParser(ag.NewChat("voice"), ag, provider)
+	p.voiceState.SetDebounceMs(20)
+	stopped := make(chan struct{})
+	go func() {
+		p.Start()
+		close(stopped)
+	}()
+
+	p.Hear("what's the weather")
+	select {
+	case got := <-provider.started:
+		if got != "what's the weather" {
+			t.Fatalf("turn started with %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no voice turn started")
+	}
+
+	// Speaking over the reply cancels it
+	p.Hear("actually wait")
+	select {
+	case <-provider.canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("barge-in did not cancel the running turn")
+	}
+
+	// The interrupting speech becomes the next turn
+	select {
+	case got := <-provider.started:
+		if got != "actually wait" {
+			t.Fatalf("next turn started with %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the interrupting utterance was not answered")
+	}
+
+	p.Hear("exit")
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start did not return on exit")
+	}
+	<-provider.canceled
+}
@@ -1,5 +1,30 @@
-export async function createSession(): Promise<string> {
-  const res = await fetch("/api/chat/session", { method: "POST" });
+export interface AgentInfo {
+  agent_id: string;
+  description: string;
+}
+
+export interface AgentList {
+  agents: AgentInfo[];
+  default: string;
+  router: boolean; // sessions created without an agent are routed per message
+}
+
+export async function getAgents(): Promise<AgentList> {
+  const res = await fetch("/api/agents");
+  if (!res.ok) {
+    const body = await res.text().catch(() => "");
+    throw new Error(body || `Failed to list agents (${res.status})`);
+  }
+  return (await res.json()) as AgentList;
+}
+
+// Create a chat session pinned to agentId, or routed per message (or using the default agent) when omitted.
+export async function createSession(agentId?: string): Promise<string> {
+  const res = await fetch("/api/chat/session", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(agentId ? { agent_id: agentId } : {}),
+  });
   if (!res.ok) {
     const body = await res.text().catch(() => "");
     throw new Error(body || `Failed to create session (${res.status})`);
@@ -24,3 +49,41 @@ export async function sendMessage(
   const data: { response: string } = await res.json();
   return data.response;
 }
+
+export type ChatEventType =
+  | "user_message"
+  | "agent_selected"
+  | "tool_call_started"
+  | "tool_result"
+  | "assistant_text_delta"
+  | "turn_complete"
+  | "error";
+
+export interface ChatEvent {
+  type: ChatEventType;
+  session_id: string;
+  text?: string;
+  agent?: string;
+  tool_call?: { tool_id: string; tool_use_id: string };
+  tool_result?: { tool_id: string; tool_use_id: string; is_error: boolean };
+  error?: string;
+  timestamp: string;
+}
+
+// Subscribe to live progress events for a session. Returns a function that closes the stream.
+export function subscribeToSession(
+  sessionId: string,
+  onEvent: (event: ChatEvent) => void,
+): () => void {
+  const protocol = window.location.protocol === "https:" ? "wss:" : "ws:";
+  const url = `${protocol}//${window.location.host}/api/chat/ws?session_id=${encodeURIComponent(sessionId)}`;
+  const socket = new WebSocket(url);
+  socket.onmessage = (msg) => {
+    try {
+      onEvent(JSON.parse(msg.data) as ChatEvent);
+    } catch {
+      // ignore malformed frames
+    }
+  };
+  return () => socket.close();
+}
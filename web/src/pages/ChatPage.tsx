@@ -1,16 +1,18 @@
 import { useEffect, useRef, useState } from "react";
 import { useLocation } from "react-router-dom";
 import { LuSendHorizontal } from "react-icons/lu";
-import { createSession, sendMessage } from "../api";
+import { createSession, sendMessage, subscribeToSession } from "../api";
+import type { ChatEvent } from "../api";
 
 interface Message {
-  role: "user" | "assistant";
+  role: "user" | "assistant" | "tool";
   content: string;
+  toolUseId?: string;
 }
 
 export default function ChatPage() {
   const { state } = useLocation();
-  const firstMessage = (state as { firstMessage?: string } | null)?.firstMessage;
+  const { firstMessage, agentId } = (state as { firstMessage?: string; agentId?: string } | null) ?? {};
 
   const [sessionId, setSessionId] = useState<string | null>(null);
   const [messages, setMessages] = useState<Message[]>([]);
@@ -32,6 +34,31 @@ export default function ChatPage() {
     el.style.height = Math.min(el.scrollHeight, 200) + "px";
   }, [input]);
 
+  // render each tool step as the backend reports it
+  useEffect(() => {
+    if (!sessionId || sessionId === "offline") return;
+    return subscribeToSession(sessionId, (event: ChatEvent) => {
+      if (event.type === "agent_selected" && event.agent) {
+        const agent = event.agent;
+        setMessages((prev) => [...prev, { role: "tool", content: `Answering as ${agent}` }]);
+      } else if (event.type === "tool_call_started" && event.tool_call) {
+        const call = event.tool_call;
+        setMessages((prev) => [
+          ...prev,
+          { role: "tool", content: `Running ${call.tool_id}…`, toolUseId: call.tool_use_id },
+        ]);
+      } else if (event.type === "tool_result" && event.tool_result) {
+        const result = event.tool_result;
+        const label = result.is_error ? `${result.tool_id} failed` : `${result.tool_id} finished`;
+        setMessages((prev) =>
+          prev.map((m) =>
+            m.role === "tool" && m.toolUseId === result.tool_use_id ? { ...m, content: label } : m,
+          ),
+        );
+      }
+    });
+  }, [sessionId]);
+
   // init session on mount, then send first message if present
   useEffect(() => {
     if (initialized.current) return;
@@ -45,7 +72,7 @@ export default function ChatPage() {
       setLoading(true);
     }
 
-    createSession()
+    createSession(agentId)
       .then(async (id) => {
         setSessionId(id);
         if (!initialMsg) return;
@@ -101,7 +128,11 @@ export default function ChatPage() {
         <div className="mx-auto max-w-3xl px-4 py-6 space-y-6">
           {messages.map((msg, i) => (
             <div key={i}>
-              {msg.role === "user" ? (
+              {msg.role === "tool" ? (
+                <div className="flex justify-start">
+                  <div className="text-sm text-stone-500">{msg.content}</div>
+                </div>
+              ) : msg.role === "user" ? (
                 <div className="flex justify-end">
                   <div className="max-w-[80%] rounded-2xl bg-stone-200/70 px-4 py-3 text-stone-900 whitespace-pre-wrap">
                     {msg.content}
@@ -1,6 +1,8 @@
 import { useEffect, useRef, useState } from "react";
 import { useNavigate } from "react-router-dom";
 import { LuSendHorizontal } from "react-icons/lu";
+import { getAgents } from "../api";
+import type { AgentList } from "../api";
 
 function getGreeting(): string {
   const h = new Date().getHours();
@@ -11,9 +13,18 @@ function getGreeting(): string {
 
 export default function LandingPage() {
   const [input, setInput] = useState("");
+  const [agents, setAgents] = useState<AgentList | null>(null);
+  const [agentId, setAgentId] = useState("");
   const inputRef = useRef<HTMLTextAreaElement>(null);
   const navigate = useNavigate();
 
+  // offer the agents only when there is a choice to make
+  useEffect(() => {
+    getAgents()
+      .then(setAgents)
+      .catch(() => setAgents(null));
+  }, []);
+
   useEffect(() => {
     const el = inputRef.current;
     if (!el) return;
@@ -24,7 +35,7 @@ export default function LandingPage() {
   function handleSend() {
     const trimmed = input.trim();
     if (!trimmed) return;
-    void navigate("/chat", { state: { firstMessage: trimmed } });
+    void navigate("/chat", { state: { firstMessage: trimmed, agentId: agentId || undefined } });
   }
 
   function handleKeyDown(e: React.KeyboardEvent) {
@@ -52,7 +63,21 @@ export default function LandingPage() {
             rows={1}
             className="w-full resize-none bg-transparent px-5 pt-4 pb-3 text-base text-stone-800 placeholder-stone-400 outline-none"
           />
-          <div className="flex items-center justify-end px-3 pb-3">
+          <div className="flex items-center justify-end gap-2 px-3 pb-3">
+            {agents && agents.agents.length > 1 && (
+              <select
+                value={agentId}
+                onChange={(e) => setAgentId(e.target.value)}
+                className="mr-auto rounded-lg bg-transparent px-2 py-1 text-sm text-stone-600 outline-none hover:bg-stone-100"
+              >
+                <option value="">{agents.router ? "Pick automatically" : `Default (${agents.default})`}</option>
+                {agents.agents.map((a) => (
+                  <option key={a.agent_id} value={a.agent_id} title={a.description}>
+                    {a.agent_id}
+                  </option>
+                ))}
+              </select>
+            )}
             <button
               onClick={handleSend}
               disabled={!input.trim()}
@@ -3,6 +3,8 @@ import react, { reactCompilerPreset } from '@vitejs/plugin-react'
 import tailwindcss from '@tailwindcss/vite'
 import babel from '@rolldown/plugin-babel'
 
+const apiTarget = process.env.VITE_API_BASE_URL ?? 'http://localhost:8080'
+
 // https://vite.dev/config/
 export default defineConfig({
   plugins: [
@@ -13,8 +15,11 @@ export default defineConfig({
   server: {
     proxy: {
       '/api': {
-        target: process.env.VITE_API_BASE_URL ?? 'http://localhost:8080',
+        target: apiTarget,
         changeOrigin: true,
+        ws: true,
+        // The API only accepts WebSockets from its own origin
+        headers: { origin: new URL(apiTarget).origin },
       },
     },
   },